@@ -0,0 +1,233 @@
+// Package bundler implements a standard ERC-4337 bundler JSON-RPC client.
+package bundler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/jsonrpc"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// UserOpBackend is implemented by every backend able to submit user operations and track their receipts.
+// Both BundlerClient and useropbuilder.UseropBuilderClient satisfy it.
+type UserOpBackend interface {
+	SendUserOp(ctx context.Context, chainID uint64, req *types.SendUserOpRequest) (*types.SendUserOpResponse, error)
+	GetUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest) (*types.UserOpReceipt, error)
+	WaitForUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest, pollInterval time.Duration, timeout time.Duration) (*types.UserOpReceipt, error)
+}
+
+var _ UserOpBackend = (*BundlerClient)(nil)
+
+// GasEstimate represents the result of eth_estimateUserOperationGas. Values are hex quantities.
+type GasEstimate struct {
+	PreVerificationGas            string `json:"preVerificationGas"`
+	VerificationGasLimit          string `json:"verificationGasLimit"`
+	CallGasLimit                  string `json:"callGasLimit"`
+	PaymasterVerificationGasLimit string `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       string `json:"paymasterPostOpGasLimit,omitempty"`
+}
+
+// UserOperationByHash represents the result of eth_getUserOperationByHash.
+type UserOperationByHash struct {
+	UserOperation   userop.RPCUserOperation `json:"userOperation"`
+	EntryPoint      string                  `json:"entryPoint"`
+	BlockNumber     string                  `json:"blockNumber"`
+	BlockHash       string                  `json:"blockHash"`
+	TransactionHash string                  `json:"transactionHash"`
+}
+
+// BundlerClient represents an ERC-4337 bundler JSON-RPC client bound to a single chain.
+type BundlerClient struct {
+	rpc *jsonrpc.Client
+
+	httpClient *http.Client
+	headers    http.Header
+
+	chainIDMu sync.Mutex
+	chainID   uint64 // cached eth_chainId result, 0 until fetched
+}
+
+// Option configures optional BundlerClient behavior.
+type Option func(*BundlerClient)
+
+// WithHTTPClient sets the HTTP client used for JSON-RPC requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *BundlerClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header sent with every JSON-RPC request, e.g. for bundler authentication.
+func WithHeader(name string, value string) Option {
+	return func(c *BundlerClient) {
+		c.headers.Add(name, value)
+	}
+}
+
+// NewBundlerClient creates a new bundler JSON-RPC client for the given RPC URL.
+func NewBundlerClient(rpcURL string, opts ...Option) *BundlerClient {
+	c := &BundlerClient{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		headers: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.rpc = jsonrpc.NewClient(rpcURL, c.httpClient, c.headers)
+	return c
+}
+
+// SendUserOperation submits a signed user operation with eth_sendUserOperation and returns its hash.
+func (c *BundlerClient) SendUserOperation(ctx context.Context, op *userop.RPCUserOperation, entryPoint string) (string, error) {
+	var hash string
+	if err := c.rpc.Call(ctx, &hash, "eth_sendUserOperation", op, entryPoint); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// EstimateUserOperationGas estimates the gas limits of a user operation with eth_estimateUserOperationGas.
+func (c *BundlerClient) EstimateUserOperationGas(ctx context.Context, op *userop.RPCUserOperation, entryPoint string) (*GasEstimate, error) {
+	var estimate GasEstimate
+	if err := c.rpc.Call(ctx, &estimate, "eth_estimateUserOperationGas", op, entryPoint); err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+// GetUserOperationReceipt fetches a receipt with eth_getUserOperationReceipt.
+// Returns types.ErrReceiptNotFound when the user operation is not included yet.
+func (c *BundlerClient) GetUserOperationReceipt(ctx context.Context, userOpHash string) (*types.UserOpReceipt, error) {
+	var raw json.RawMessage
+	if err := c.rpc.Call(ctx, &raw, "eth_getUserOperationReceipt", userOpHash); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, types.ErrReceiptNotFound
+	}
+
+	var receipt types.UserOpReceipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, fmt.Errorf("failed to decode receipt: %w", err)
+	}
+	return &receipt, nil
+}
+
+// GetUserOperationByHash fetches a user operation with eth_getUserOperationByHash.
+// Returns nil without error when the bundler does not know the hash.
+func (c *BundlerClient) GetUserOperationByHash(ctx context.Context, userOpHash string) (*UserOperationByHash, error) {
+	var result *UserOperationByHash
+	if err := c.rpc.Call(ctx, &result, "eth_getUserOperationByHash", userOpHash); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SupportedEntryPoints returns the EntryPoint addresses supported by the bundler.
+func (c *BundlerClient) SupportedEntryPoints(ctx context.Context) ([]string, error) {
+	var entryPoints []string
+	if err := c.rpc.Call(ctx, &entryPoints, "eth_supportedEntryPoints"); err != nil {
+		return nil, err
+	}
+	return entryPoints, nil
+}
+
+// ChainID returns the chain ID reported by the bundler with eth_chainId.
+func (c *BundlerClient) ChainID(ctx context.Context) (uint64, error) {
+	var chainID hexutil.Uint64
+	if err := c.rpc.Call(ctx, &chainID, "eth_chainId"); err != nil {
+		return 0, err
+	}
+	return uint64(chainID), nil
+}
+
+// SendUserOp sends a signed user operation, matching UseropBuilderClient.SendUserOp.
+// The chain ID must match the bundler's chain.
+func (c *BundlerClient) SendUserOp(ctx context.Context, chainID uint64, req *types.SendUserOpRequest) (*types.SendUserOpResponse, error) {
+	if err := c.checkChainID(ctx, chainID); err != nil {
+		return nil, err
+	}
+
+	version := constants.EntryPointVersion(req.EntryPointVersion)
+	entryPoint, err := constants.GetEntryPointAddress(version)
+	if err != nil {
+		return nil, err
+	}
+	rpcOp, err := userop.ToRPC(&req.BuildUserOpResponse, req.Signature, version)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := c.SendUserOperation(ctx, rpcOp, entryPoint)
+	if err != nil {
+		return nil, err
+	}
+	return &types.SendUserOpResponse{UserOpHash: hash}, nil
+}
+
+// GetUserOpReceipt gets the receipt for a user operation, matching UseropBuilderClient.GetUserOpReceipt.
+func (c *BundlerClient) GetUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest) (*types.UserOpReceipt, error) {
+	if err := c.checkChainID(ctx, chainID); err != nil {
+		return nil, err
+	}
+	return c.GetUserOperationReceipt(ctx, req.UserOpHash)
+}
+
+// WaitForUserOpReceipt polls for the user operation receipt until it's available or timeout is reached.
+func (c *BundlerClient) WaitForUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest, pollInterval time.Duration, timeout time.Duration) (*types.UserOpReceipt, error) {
+	if pollInterval == 0 {
+		pollInterval = 2 * time.Second
+	}
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	attempts := 0
+	for {
+		attempts++
+		receipt, err := c.GetUserOpReceipt(timeoutCtx, chainID, req)
+		if err == nil {
+			return receipt, nil
+		}
+
+		select {
+		case <-timeoutCtx.Done():
+			return nil, fmt.Errorf("timed out waiting for user operation receipt after %d attempts: %w", attempts, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkChainID verifies that chainID matches the bundler's chain, querying it once.
+func (c *BundlerClient) checkChainID(ctx context.Context, chainID uint64) error {
+	c.chainIDMu.Lock()
+	defer c.chainIDMu.Unlock()
+
+	if c.chainID == 0 {
+		bundlerChainID, err := c.ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get bundler chain id: %w", err)
+		}
+		c.chainID = bundlerChainID
+	}
+	if c.chainID != chainID {
+		return fmt.Errorf("chain id mismatch: bundler is on chain %d, requested %d", c.chainID, chainID)
+	}
+	return nil
+}
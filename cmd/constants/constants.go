@@ -2,6 +2,9 @@ package constants
 
 import "fmt"
 
+// SDKVersion is the version of this SDK reported to ZeroDev services.
+const SDKVersion = "0.1.0"
+
 // KernelVersion represents a supported kernel version.
 type KernelVersion string
 
@@ -12,6 +15,25 @@ const (
 	KernelVersion033 KernelVersion = "0.3.3"
 )
 
+// ECDSAValidatorAddress is the Kernel v3 ECDSA validator used as the default root validator.
+const ECDSAValidatorAddress = "0x845ADb2C711129d4f3966735eD98a9F09fC4cE57"
+
+// WebAuthnValidatorAddress is the Kernel v3 WebAuthn validator for P-256 passkeys.
+const WebAuthnValidatorAddress = "0xbA45a2BFb8De3D24cA9D7F1B551E14dFF5d690Fd"
+
+// WeightedECDSAValidatorAddress is the Kernel v3 weighted ECDSA (multisig) validator.
+const WeightedECDSAValidatorAddress = "0xeD89244160CfE273800B58b1B534031699dFeEEE"
+
+// Kernel v3 permission validator modules used by session keys
+const (
+	ECDSASignerAddress     = "0x6A6F069E2a08c2468e7724Ab3250CdBFBA14D4FF"
+	SudoPolicyAddress      = "0x67b436caD8a6D025DF6C82C5BB43fbF11fC5B9B7"
+	CallPolicyAddress      = "0x9a52283276A0ec8740DF50bF01B28A80D880eaf2"
+	GasPolicyAddress       = "0xaeFC5AbC67FfD258abD0A3E54f65E70326F84b23"
+	RateLimitPolicyAddress = "0xf63d4139B25c836334edD76641356c6b74C86873"
+	TimestampPolicyAddress = "0xB9f8f524bE6EcD8C945b1b87f9ae5C192FdCE20F"
+)
+
 // KernelAddresses contains deployment addresses for a specific kernel version.
 type KernelAddresses struct {
 	AccountImplementationAddress string
@@ -42,6 +64,23 @@ var KernelVersionToAddressesMap = map[KernelVersion]KernelAddresses{
 	},
 }
 
+// EntryPointVersion represents a supported ERC-4337 EntryPoint version.
+type EntryPointVersion string
+
+// Supported EntryPoint versions
+const (
+	EntryPointVersion06 EntryPointVersion = "0.6"
+	EntryPointVersion07 EntryPointVersion = "0.7"
+	EntryPointVersion08 EntryPointVersion = "0.8"
+)
+
+// EntryPointVersionToAddressMap maps EntryPoint versions to their canonical deployment addresses.
+var EntryPointVersionToAddressMap = map[EntryPointVersion]string{
+	EntryPointVersion06: "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789",
+	EntryPointVersion07: "0x0000000071727De22E5E9d8BAf0edAc6f37da032",
+	EntryPointVersion08: "0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108",
+}
+
 // GetAccountImplementationAddress returns the account implementation address for a given kernel version
 func GetAccountImplementationAddress(version KernelVersion) (string, error) {
 	addresses, ok := KernelVersionToAddressesMap[version]
@@ -59,3 +98,12 @@ func GetKernelAddresses(version KernelVersion) (KernelAddresses, error) {
 	}
 	return addresses, nil
 }
+
+// GetEntryPointAddress returns the EntryPoint address for a given EntryPoint version
+func GetEntryPointAddress(version EntryPointVersion) (string, error) {
+	address, ok := EntryPointVersionToAddressMap[version]
+	if !ok {
+		return "", fmt.Errorf("unsupported entrypoint version: %s", version)
+	}
+	return address, nil
+}
@@ -0,0 +1,488 @@
+// Package dispatcher runs many user operations through the build, sign, send and receipt steps with a
+// bounded worker pool, keeping user operations of the same sender and nonce lane in submission order.
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/journal"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/nonce"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+	"github.com/zerodevapp/sdk-go/cmd/useropbuilder"
+)
+
+var (
+	// ErrClosed is returned when submitting a job to a closed dispatcher.
+	ErrClosed = errors.New("dispatcher is closed")
+	// ErrQueueFull is returned by TrySubmit when the dispatcher queue is full.
+	ErrQueueFull = errors.New("dispatcher queue is full")
+)
+
+// Builder is the subset of the UserOp builder API used by Dispatcher, satisfied by *useropbuilder.UseropBuilderClient.
+type Builder interface {
+	BuildUserOp(ctx context.Context, chainID uint64, req *types.BuildUserOpRequest) (*types.BuildUserOpResponse, error)
+	SendUserOp(ctx context.Context, chainID uint64, req *types.SendUserOpRequest) (*types.SendUserOpResponse, error)
+	WaitForUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest, pollInterval time.Duration, timeout time.Duration) (*types.UserOpReceipt, error)
+}
+
+var _ Builder = (*useropbuilder.UseropBuilderClient)(nil)
+
+// Job is a user operation to build, sign with Signer, send and wait for.
+// Jobs with the same Request.Account and Lane run one after the other in submission order.
+type Job struct {
+	ID       string // Optional identifier reported back in the Result
+	ChainID  uint64
+	Request  *types.BuildUserOpRequest
+	Signer   signer.Signer
+	Lane     string       // Nonce lane; jobs on different lanes of a sender run concurrently when a NonceManager is set
+	Callback func(Result) // Optional, called with the result before it is delivered on the result channel
+}
+
+// Result is the outcome of a Job.
+type Result struct {
+	JobID      string
+	UserOpHash string
+	Receipt    *types.UserOpReceipt
+	Err        error
+}
+
+// Option configures a Dispatcher.
+type Option func(*Dispatcher)
+
+// WithWorkers sets the number of jobs processed concurrently. Defaults to 8.
+func WithWorkers(workers int) Option {
+	return func(d *Dispatcher) {
+		d.workers = workers
+	}
+}
+
+// WithQueueSize sets how many jobs can wait for a worker before Submit blocks. Defaults to 1024.
+func WithQueueSize(size int) Option {
+	return func(d *Dispatcher) {
+		d.queueSize = size
+	}
+}
+
+// WithMaxConcurrentRequests caps concurrent build and send calls to the builder API. Defaults to the number of workers.
+func WithMaxConcurrentRequests(n int) Option {
+	return func(d *Dispatcher) {
+		d.maxRequests = n
+	}
+}
+
+// WithNonceManager makes the dispatcher allocate nonces itself, so a lane can send its next user operation
+// without waiting for the previous one to be included. Without it, the builder picks the nonce and each
+// lane waits for the receipt of a user operation before building the next one.
+func WithNonceManager(m nonce.NonceManager) Option {
+	return func(d *Dispatcher) {
+		d.nonces = m
+	}
+}
+
+// WithJournal records every lifecycle transition of the dispatched user operations in j,
+// so that user operations in flight when the process stops can be resumed with journal.Resume.
+func WithJournal(j journal.Journal) Option {
+	return func(d *Dispatcher) {
+		d.journal = j
+	}
+}
+
+// WithReceiptPolling sets the poll interval and timeout used to wait for receipts.
+// Zero values use the builder's defaults.
+func WithReceiptPolling(pollInterval, timeout time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.pollInterval = pollInterval
+		d.receiptTimeout = timeout
+	}
+}
+
+// laneKey identifies a sender's nonce lane on a chain.
+type laneKey struct {
+	chainID uint64
+	sender  common.Address
+	lane    string
+}
+
+// task is a queued job with its context and result channel.
+type task struct {
+	ctx    context.Context
+	job    *Job
+	result chan Result
+}
+
+// lane is the FIFO of tasks of one laneKey. A lane is scheduled on ready at most once at a time.
+type lane struct {
+	tasks  []*task
+	active bool
+}
+
+// Dispatcher processes jobs with a bounded worker pool.
+type Dispatcher struct {
+	builder        Builder
+	nonces         nonce.NonceManager
+	journal        journal.Journal
+	workers        int
+	queueSize      int
+	maxRequests    int
+	pollInterval   time.Duration
+	receiptTimeout time.Duration
+
+	slots    chan struct{} // One per queued or running job, for backpressure
+	requests chan struct{} // One per builder API call in flight
+	ready    chan laneKey  // Lanes with a task to run
+
+	mu      sync.Mutex
+	lanes   map[laneKey]*lane
+	closed  bool
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher sending through builder and starts its workers.
+func NewDispatcher(builder Builder, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		builder:   builder,
+		workers:   8,
+		queueSize: 1024,
+		lanes:     make(map[laneKey]*lane),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.workers = max(d.workers, 1)
+	d.queueSize = max(d.queueSize, 1)
+	if d.maxRequests <= 0 {
+		d.maxRequests = d.workers
+	}
+
+	d.slots = make(chan struct{}, d.queueSize)
+	d.requests = make(chan struct{}, d.maxRequests)
+	d.ready = make(chan laneKey, d.queueSize)
+
+	d.wg.Add(d.workers)
+	for range d.workers {
+		go d.work()
+	}
+	return d
+}
+
+// Submit queues a job, blocking while the queue is full, and returns a channel receiving its result.
+// ctx applies to queueing as well as to processing the job.
+func (d *Dispatcher) Submit(ctx context.Context, job *Job) (<-chan Result, error) {
+	if err := validateJob(job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return d.enqueue(ctx, job)
+}
+
+// TrySubmit queues a job like Submit, but returns ErrQueueFull instead of blocking when the queue is full.
+func (d *Dispatcher) TrySubmit(ctx context.Context, job *Job) (<-chan Result, error) {
+	if err := validateJob(job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		return nil, ErrQueueFull
+	}
+	return d.enqueue(ctx, job)
+}
+
+// Close stops accepting jobs and waits for queued jobs to finish.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	d.closed = true
+	d.stop()
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// enqueue appends a task to its lane and schedules the lane when it is idle.
+func (d *Dispatcher) enqueue(ctx context.Context, job *Job) (<-chan Result, error) {
+	t := &task{ctx: ctx, job: job, result: make(chan Result, 1)}
+	key := laneKey{chainID: job.ChainID, sender: common.HexToAddress(job.Request.Account), lane: job.Lane}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		d.freeSlot()
+		return nil, ErrClosed
+	}
+
+	l, ok := d.lanes[key]
+	if !ok {
+		l = &lane{}
+		d.lanes[key] = l
+	}
+	l.tasks = append(l.tasks, t)
+	if !l.active {
+		l.active = true
+		d.ready <- key
+	}
+	return t.result, nil
+}
+
+// work runs tasks of ready lanes until the dispatcher is closed and drained.
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for key := range d.ready {
+		d.mu.Lock()
+		l := d.lanes[key]
+		t := l.tasks[0]
+		l.tasks = l.tasks[1:]
+		d.mu.Unlock()
+
+		d.run(key, t)
+	}
+}
+
+// run processes a task. The lane is released as soon as the next task may start: after sending when
+// nonces are allocated locally, after the receipt otherwise.
+func (d *Dispatcher) run(key laneKey, t *task) {
+	released := false
+	release := func() {
+		if !released {
+			released = true
+			d.release(key)
+		}
+	}
+
+	result := Result{JobID: t.job.ID}
+	entry, err := d.send(t)
+	result.UserOpHash, result.Err = entry.UserOpHash, err
+	if result.Err == nil && d.nonces != nil {
+		release()
+	}
+	if result.Err == nil {
+		result.Receipt, result.Err = d.builder.WaitForUserOpReceipt(t.ctx, t.job.ChainID, &types.GetUserOpReceiptRequest{UserOpHash: result.UserOpHash}, d.pollInterval, d.receiptTimeout)
+		if err := d.recordReceipt(t.ctx, entry, result.Receipt, result.Err); err != nil {
+			result.Err = errors.Join(result.Err, err)
+		}
+	}
+	release()
+
+	if t.job.Callback != nil {
+		t.job.Callback(result)
+	}
+	t.result <- result
+	close(t.result)
+	d.finish()
+}
+
+// send builds, verifies, signs and sends the user operation of a task and returns its journal entry.
+func (d *Dispatcher) send(t *task) (journal.Entry, error) {
+	if err := t.ctx.Err(); err != nil {
+		return journal.Entry{}, err
+	}
+	job := t.job
+	req := *job.Request
+
+	var allocated *big.Int
+	if d.nonces != nil {
+		next, err := d.nonces.Next(t.ctx, common.HexToAddress(req.Account), job.Lane)
+		if err != nil {
+			return journal.Entry{}, fmt.Errorf("failed to allocate nonce: %w", err)
+		}
+		allocated = next
+		req.Nonce = hexutil.EncodeBig(next)
+	}
+
+	entry, err := d.buildAndSend(t.ctx, job, &req)
+	if err != nil && allocated != nil && entry.State != journal.StateSent && entry.State != journal.StateSigned {
+		// The allocated nonce was not used, later user operations of the lane would be stuck behind it.
+		// It is only given back while no later nonce of the lane was handed out. A signed user operation
+		// keeps its nonce, since it may have reached the bundler.
+		if _, releaseErr := d.nonces.Release(t.ctx, common.HexToAddress(req.Account), job.Lane, allocated); releaseErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release nonce: %w", releaseErr))
+		}
+	}
+	return entry, err
+}
+
+// buildAndSend runs the builder API calls of a job, within the concurrent request cap.
+// Each step is recorded in the journal when one is configured.
+func (d *Dispatcher) buildAndSend(ctx context.Context, job *Job, req *types.BuildUserOpRequest) (journal.Entry, error) {
+	var op *types.BuildUserOpResponse
+	err := d.request(ctx, func() (err error) {
+		op, err = d.builder.BuildUserOp(ctx, job.ChainID, req)
+		return err
+	})
+	if err != nil {
+		return journal.Entry{}, fmt.Errorf("failed to build user operation: %w", err)
+	}
+	if err := kernel.VerifyBuildResponse(req, op); err != nil {
+		return journal.Entry{}, err
+	}
+	if req.Nonce != "" {
+		if err := checkNonce(req.Nonce, op.Nonce); err != nil {
+			return journal.Entry{}, err
+		}
+	}
+	userOpHash, err := userop.VerifyUserOpHash(op, constants.EntryPointVersion(req.Entrypoint), job.ChainID)
+	if err != nil {
+		return journal.Entry{}, err
+	}
+
+	entry := journal.Entry{
+		ChainID:           job.ChainID,
+		UserOpHash:        userOpHash.Hex(),
+		State:             journal.StateBuilt,
+		EntryPointVersion: req.Entrypoint,
+		UserOp:            op,
+	}
+	if err := d.record(ctx, entry); err != nil {
+		return entry, err
+	}
+
+	signature, err := signer.SignUserOp(ctx, op, constants.EntryPointVersion(req.Entrypoint), job.ChainID, job.Signer)
+	if err != nil {
+		return entry, errors.Join(err, d.fail(ctx, &entry, journal.StateFailed, err))
+	}
+	entry.State, entry.Signature = journal.StateSigned, signature
+	if err := d.record(ctx, entry); err != nil {
+		return entry, err
+	}
+
+	err = d.request(ctx, func() (err error) {
+		_, err = d.builder.SendUserOp(ctx, job.ChainID, &types.SendUserOpRequest{
+			BuildUserOpResponse: *op,
+			EntryPointVersion:   req.Entrypoint,
+			Signature:           signature,
+		})
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to send user operation: %w", err)
+		if errors.Is(err, useropbuilder.ErrValidationFailed) {
+			// Rejected by the bundler validation. Other errors, such as server or gateway errors, leave the
+			// user operation signed, since it may have reached the bundler; journal.Resume settles it.
+			err = errors.Join(err, d.fail(ctx, &entry, journal.StateFailed, err))
+		}
+		return entry, err
+	}
+	entry.State = journal.StateSent
+	return entry, d.record(ctx, entry)
+}
+
+// record stores a journal entry when a journal is configured.
+func (d *Dispatcher) record(ctx context.Context, entry journal.Entry) error {
+	if d.journal == nil {
+		return nil
+	}
+	entry.UpdatedAt = time.Time{}
+	if err := d.journal.Record(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record user operation %s as %s: %w", entry.UserOpHash, entry.State, err)
+	}
+	return nil
+}
+
+// fail moves entry to a final state after cause and records it.
+func (d *Dispatcher) fail(ctx context.Context, entry *journal.Entry, state journal.State, cause error) error {
+	entry.State, entry.Error = state, cause.Error()
+	return d.record(ctx, *entry)
+}
+
+// recordReceipt records the receipt of a sent user operation. A user operation without a receipt, e.g. after
+// the receipt timeout or when the job context ended, is left as sent: it may still be included, and
+// journal.Resume tracks it again.
+func (d *Dispatcher) recordReceipt(ctx context.Context, entry journal.Entry, receipt *types.UserOpReceipt, waitErr error) error {
+	if d.journal == nil || waitErr != nil {
+		return nil
+	}
+	_, err := journal.RecordReceipt(ctx, d.journal, entry, receipt)
+	return err
+}
+
+// request runs fn while holding one of the concurrent builder request slots.
+func (d *Dispatcher) request(ctx context.Context, fn func() error) error {
+	select {
+	case d.requests <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-d.requests }()
+	return fn()
+}
+
+// release schedules the lane again when it has more tasks, or marks it idle.
+func (d *Dispatcher) release(key laneKey) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l := d.lanes[key]
+	if len(l.tasks) > 0 {
+		d.ready <- key
+		return
+	}
+	l.active = false
+	delete(d.lanes, key)
+}
+
+// finish frees the queue slot of a completed task.
+func (d *Dispatcher) finish() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.freeSlot()
+}
+
+// freeSlot frees a queue slot. Must be called with d.mu held.
+func (d *Dispatcher) freeSlot() {
+	<-d.slots
+	d.stop()
+}
+
+// stop stops the workers once the dispatcher is closed and no job is queued or running. Must be called with d.mu held.
+func (d *Dispatcher) stop() {
+	if d.closed && !d.stopped && len(d.slots) == 0 {
+		d.stopped = true
+		close(d.ready)
+	}
+}
+
+// checkNonce checks that the builder used the requested nonce.
+func checkNonce(requested, built string) error {
+	want, err := userop.ParseUint256(requested)
+	if err != nil {
+		return fmt.Errorf("invalid requested nonce: %w", err)
+	}
+	got, err := userop.ParseUint256(built)
+	if err != nil {
+		return fmt.Errorf("invalid built nonce: %w", err)
+	}
+	if want.Cmp(got) != 0 {
+		return fmt.Errorf("builder returned nonce %s, requested %s", built, requested)
+	}
+	return nil
+}
+
+// validateJob checks the fields a job needs before it is queued.
+func validateJob(job *Job) error {
+	if job == nil || job.Request == nil {
+		return fmt.Errorf("job request is required")
+	}
+	if job.Signer == nil {
+		return fmt.Errorf("job signer is required")
+	}
+	if !common.IsHexAddress(job.Request.Account) {
+		return fmt.Errorf("invalid account address: %q", job.Request.Account)
+	}
+	return nil
+}
@@ -0,0 +1,539 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/journal"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/nonce"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+	"github.com/zerodevapp/sdk-go/cmd/useropbuilder"
+)
+
+const testChainID = 8453
+
+var (
+	senderA = "0x1111111111111111111111111111111111111111"
+	senderB = "0x2222222222222222222222222222222222222222"
+)
+
+// fakeBuilder is a builder API answering build, send and receipt calls of Kernel v0.3.3 user operations
+// on EntryPoint v0.7. Every user operation is included as soon as it is sent.
+type fakeBuilder struct {
+	onBuild func(req *types.BuildUserOpRequest) int // Optional, returns the status to answer with, 0 for success
+	onSend  func(w http.ResponseWriter) bool        // Optional, returns whether it answered the send call
+	pending bool                                    // Set to never return receipts
+
+	mu          sync.Mutex
+	builds      []string // sender/data of every build call, in arrival order
+	inFlight    int      // Build and send calls in progress
+	maxInFlight int
+}
+
+func (f *fakeBuilder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if endpoint == useropbuilder.EndpointGetUserOpReceipt {
+		var req types.GetUserOpReceiptRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if f.pending {
+			w.Write([]byte(`{"error":"receipt not found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(types.UserOpReceipt{UserOpHash: req.UserOpHash, Success: true})
+		return
+	}
+
+	f.mu.Lock()
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	switch endpoint {
+	case useropbuilder.EndpointBuildUserOp:
+		var req types.BuildUserOpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.builds = append(f.builds, req.Account+"/"+req.Calls[0].Data)
+		f.mu.Unlock()
+		if f.onBuild != nil {
+			if status := f.onBuild(&req); status != 0 {
+				http.Error(w, `{"message":"rejected"}`, status)
+				return
+			}
+		}
+		op, err := buildUserOp(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(op)
+	case useropbuilder.EndpointSendUserOp:
+		var req types.SendUserOpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if f.onSend != nil && f.onSend(w) {
+			return
+		}
+		json.NewEncoder(w).Encode(types.SendUserOpResponse{UserOpHash: req.UserOpHash})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// buildsOf returns the data of the calls built for sender, in build order.
+func (f *fakeBuilder) buildsOf(sender string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var data []string
+	for _, build := range f.builds {
+		if s, d, _ := strings.Cut(build, "/"); s == sender {
+			data = append(data, d)
+		}
+	}
+	return data
+}
+
+// buildUserOp returns the user operation executing the requested calls, with the requested nonce or nonce 0.
+func buildUserOp(req *types.BuildUserOpRequest) (*types.BuildUserOpResponse, error) {
+	callData, err := kernel.EncodeCallData(constants.KernelVersion(req.KernelVersion), req.Calls)
+	if err != nil {
+		return nil, err
+	}
+	op := &types.BuildUserOpResponse{
+		Sender:               req.Account,
+		Nonce:                req.Nonce,
+		CallData:             callData,
+		CallGasLimit:         "100000",
+		VerificationGasLimit: "200000",
+		PreVerificationGas:   "50000",
+		MaxFeePerGas:         "3000000000",
+		MaxPriorityFeePerGas: "1000000000",
+	}
+	if op.Nonce == "" {
+		op.Nonce = "0x0"
+	}
+	hash, err := userop.GetUserOpHash(op, constants.EntryPointVersion(req.Entrypoint), testChainID)
+	if err != nil {
+		return nil, err
+	}
+	op.UserOpHash = hash.Hex()
+	return op, nil
+}
+
+// fakeNonceBackend reports sequence 0 for every account and key.
+type fakeNonceBackend struct{}
+
+func (fakeNonceBackend) GetNonce(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error) {
+	return nonce.Encode(key, 0)
+}
+
+// newTestDispatcher starts a fake builder and a dispatcher sending to it. Both are closed with the test.
+func newTestDispatcher(t *testing.T, fake *fakeBuilder, opts ...Option) *Dispatcher {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	// No keep-alive, so that no connection goroutines outlive a request.
+	httpClient := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	builder := useropbuilder.NewUserOpBuilderWithHTTPClient("project", server.URL, "key", httpClient, useropbuilder.WithRetryPolicy(useropbuilder.NoRetry()))
+	d := NewDispatcher(builder, append([]Option{WithReceiptPolling(10*time.Millisecond, 5*time.Second)}, opts...)...)
+	t.Cleanup(d.Close)
+	return d
+}
+
+// newJob returns a job of sender on lane whose single call carries data.
+func newJob(t *testing.T, sender, lane string, data byte) *Job {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return &Job{
+		ID:      fmt.Sprintf("%s/%s/%d", sender, lane, data),
+		ChainID: testChainID,
+		Request: &types.BuildUserOpRequest{
+			Account:       sender,
+			Entrypoint:    string(constants.EntryPointVersion07),
+			KernelVersion: string(constants.KernelVersion033),
+			Calls:         []types.Call{{To: "0x3333333333333333333333333333333333333333", Value: "0", Data: fmt.Sprintf("0x%02x", data)}},
+		},
+		Signer: signer.NewPrivateKeySigner(key),
+		Lane:   lane,
+	}
+}
+
+// wait returns the result of a submitted job, failing the test when it does not arrive in time.
+func wait(t *testing.T, results <-chan Result) Result {
+	t.Helper()
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a job result")
+		return Result{}
+	}
+}
+
+func TestDispatcherLaneOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"builder nonces", nil},
+		{"nonce manager", []Option{WithNonceManager(nonce.NewMemoryNonceManager(fakeNonceBackend{}))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBuilder{}
+			d := newTestDispatcher(t, fake, append([]Option{WithWorkers(4)}, tt.opts...)...)
+
+			ctx := context.Background()
+			var results []<-chan Result
+			var want []string
+			for i := range 5 {
+				for _, sender := range []string{senderA, senderB} {
+					result, err := d.Submit(ctx, newJob(t, sender, "", byte(i)))
+					if err != nil {
+						t.Fatalf("Submit() error = %v", err)
+					}
+					results = append(results, result)
+				}
+				want = append(want, fmt.Sprintf("0x%02x", i))
+			}
+			for _, result := range results {
+				if r := wait(t, result); r.Err != nil || r.Receipt == nil || r.Receipt.UserOpHash != r.UserOpHash {
+					t.Fatalf("job %s result = %+v", r.JobID, r)
+				}
+			}
+
+			for _, sender := range []string{senderA, senderB} {
+				if got := fake.buildsOf(sender); strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("builds of %s = %v, want %v", sender, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcherParallelLanes(t *testing.T) {
+	tests := []struct {
+		name   string
+		jobs   [][2]string // sender, lane
+		nonces bool
+	}{
+		{"senders", [][2]string{{senderA, ""}, {senderB, ""}}, false},
+		{"lanes of a sender", [][2]string{{senderA, "a"}, {senderA, "b"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every build waits until all jobs are being built, which only happens when the lanes run in parallel.
+			var arrived sync.WaitGroup
+			arrived.Add(len(tt.jobs))
+			all := make(chan struct{})
+			go func() {
+				arrived.Wait()
+				close(all)
+			}()
+			fake := &fakeBuilder{onBuild: func(*types.BuildUserOpRequest) int {
+				arrived.Done()
+				select {
+				case <-all:
+					return 0
+				case <-time.After(2 * time.Second):
+					return http.StatusServiceUnavailable
+				}
+			}}
+			opts := []Option{WithWorkers(len(tt.jobs))}
+			if tt.nonces {
+				opts = append(opts, WithNonceManager(nonce.NewMemoryNonceManager(fakeNonceBackend{})))
+			}
+			d := newTestDispatcher(t, fake, opts...)
+
+			var results []<-chan Result
+			for i, job := range tt.jobs {
+				result, err := d.Submit(context.Background(), newJob(t, job[0], job[1], byte(i)))
+				if err != nil {
+					t.Fatalf("Submit() error = %v", err)
+				}
+				results = append(results, result)
+			}
+			for _, result := range results {
+				if r := wait(t, result); r.Err != nil {
+					t.Errorf("job %s error = %v", r.JobID, r.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcherBackpressure(t *testing.T) {
+	unblock := make(chan struct{})
+	fake := &fakeBuilder{onBuild: func(*types.BuildUserOpRequest) int {
+		<-unblock
+		return 0
+	}}
+	d := newTestDispatcher(t, fake, WithWorkers(1), WithQueueSize(1))
+	ctx := context.Background()
+
+	first, err := d.Submit(ctx, newJob(t, senderA, "", 1))
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := d.TrySubmit(ctx, newJob(t, senderA, "", 2)); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TrySubmit() on a full queue error = %v, want %v", err, ErrQueueFull)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := d.Submit(timeoutCtx, newJob(t, senderA, "", 2)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit() on a full queue error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	submitted := make(chan error, 1)
+	var second <-chan Result
+	go func() {
+		var err error
+		second, err = d.Submit(ctx, newJob(t, senderB, "", 2))
+		submitted <- err
+	}()
+	select {
+	case err := <-submitted:
+		t.Fatalf("Submit() returned %v while the queue was full", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(unblock)
+	if r := wait(t, first); r.Err != nil {
+		t.Errorf("first job error = %v", r.Err)
+	}
+	if err := <-submitted; err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if r := wait(t, second); r.Err != nil {
+		t.Errorf("second job error = %v", r.Err)
+	}
+}
+
+func TestDispatcherMaxConcurrentRequests(t *testing.T) {
+	fake := &fakeBuilder{onBuild: func(*types.BuildUserOpRequest) int {
+		time.Sleep(20 * time.Millisecond)
+		return 0
+	}}
+	d := newTestDispatcher(t, fake, WithWorkers(8), WithMaxConcurrentRequests(2))
+
+	var results []<-chan Result
+	for i := range 8 {
+		sender := common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+		result, err := d.Submit(context.Background(), newJob(t, sender, "", byte(i)))
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		results = append(results, result)
+	}
+	for _, result := range results {
+		if r := wait(t, result); r.Err != nil {
+			t.Errorf("job %s error = %v", r.JobID, r.Err)
+		}
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.maxInFlight > 2 {
+		t.Errorf("concurrent builder requests = %d, want at most 2", fake.maxInFlight)
+	}
+}
+
+func TestDispatcherReleasesUnsentNonce(t *testing.T) {
+	fake := &fakeBuilder{onBuild: func(req *types.BuildUserOpRequest) int {
+		if req.Calls[0].Data == "0x01" {
+			return http.StatusBadRequest
+		}
+		return 0
+	}}
+	nonces := nonce.NewMemoryNonceManager(fakeNonceBackend{})
+	d := newTestDispatcher(t, fake, WithNonceManager(nonces))
+	ctx := context.Background()
+
+	for i, wantErr := range []bool{true, false} {
+		result, err := d.Submit(ctx, newJob(t, senderA, "", byte(i+1)))
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		if r := wait(t, result); (r.Err != nil) != wantErr {
+			t.Fatalf("job %s error = %v, want error %v", r.JobID, r.Err, wantErr)
+		}
+	}
+
+	// The nonce of the rejected job was given back to the next job.
+	next, err := nonces.Next(ctx, common.HexToAddress(senderA), "")
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if _, sequence := nonce.Decode(next); sequence != 1 {
+		t.Errorf("Next() sequence = %d, want 1", sequence)
+	}
+}
+
+func TestDispatcherSendFailure(t *testing.T) {
+	// A bundler validation rejection, in the shape of a proxied JSON-RPC error.
+	const rejection = `{"message":"AA21 didn't pay prefund","code":-32500}`
+
+	tests := []struct {
+		name         string
+		onSend       func(w http.ResponseWriter) bool
+		wantState    journal.State // Journal state of the user operation, empty when it is no longer in flight
+		wantSequence uint64        // Sequence of the next nonce of the lane
+	}{
+		{"validation rejection", func(w http.ResponseWriter) bool {
+			http.Error(w, rejection, http.StatusBadRequest)
+			return true
+		}, "", 0},
+		{"server error", func(w http.ResponseWriter) bool {
+			http.Error(w, `{"message":"internal error"}`, http.StatusInternalServerError)
+			return true
+		}, journal.StateSigned, 1},
+		{"gateway error", func(w http.ResponseWriter) bool {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return true
+		}, journal.StateSigned, 1},
+		{"connection lost", func(w http.ResponseWriter) bool {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return true
+		}, journal.StateSigned, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeBuilder{onSend: tt.onSend}
+			nonces := nonce.NewMemoryNonceManager(fakeNonceBackend{})
+			j := journal.NewMemoryJournal()
+			d := newTestDispatcher(t, fake, WithNonceManager(nonces), WithJournal(j))
+			ctx := context.Background()
+
+			result, err := d.Submit(ctx, newJob(t, senderA, "", 1))
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			if r := wait(t, result); r.Err == nil {
+				t.Fatal("job succeeded although its send failed")
+			}
+
+			// Only a rejected user operation is final and gives back its nonce; any other may have reached the bundler.
+			entries, err := j.InFlight(ctx)
+			if err != nil {
+				t.Fatalf("InFlight() error = %v", err)
+			}
+			var state journal.State
+			if len(entries) > 0 {
+				state = entries[0].State
+			}
+			if len(entries) > 1 || state != tt.wantState {
+				t.Errorf("InFlight() = %+v, want state %q", entries, tt.wantState)
+			}
+			next, err := nonces.Next(ctx, common.HexToAddress(senderA), "")
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+			if _, sequence := nonce.Decode(next); sequence != tt.wantSequence {
+				t.Errorf("Next() sequence = %d, want %d", sequence, tt.wantSequence)
+			}
+		})
+	}
+}
+
+func TestDispatcherReceiptTimeout(t *testing.T) {
+	fake := &fakeBuilder{pending: true}
+	j := journal.NewMemoryJournal()
+	d := newTestDispatcher(t, fake, WithJournal(j), WithReceiptPolling(10*time.Millisecond, 50*time.Millisecond))
+	ctx := context.Background()
+
+	result, err := d.Submit(ctx, newJob(t, senderA, "", 1))
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	r := wait(t, result)
+	if r.Err == nil {
+		t.Fatal("job succeeded without a receipt")
+	}
+
+	// The user operation may still be included, so it stays in flight for journal.Resume.
+	entries, err := j.InFlight(ctx)
+	if err != nil {
+		t.Fatalf("InFlight() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].State != journal.StateSent || entries[0].UserOpHash != r.UserOpHash {
+		t.Errorf("InFlight() = %+v, want %s as sent", entries, r.UserOpHash)
+	}
+}
+
+func TestDispatcherClose(t *testing.T) {
+	fake := &fakeBuilder{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	httpClient := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	builder := useropbuilder.NewUserOpBuilderWithHTTPClient("project", server.URL, "key", httpClient, useropbuilder.WithRetryPolicy(useropbuilder.NoRetry()))
+
+	before := runtime.NumGoroutine()
+	d := NewDispatcher(builder, WithWorkers(4), WithReceiptPolling(10*time.Millisecond, 5*time.Second))
+	var results []<-chan Result
+	for i := range 10 {
+		sender := []string{senderA, senderB}[i%2]
+		result, err := d.Submit(context.Background(), newJob(t, sender, "", byte(i)))
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		results = append(results, result)
+	}
+	d.Close()
+
+	// Close returns once every queued job finished, so results are already delivered.
+	for _, result := range results {
+		select {
+		case r := <-result:
+			if r.Err != nil {
+				t.Errorf("job %s error = %v", r.JobID, r.Err)
+			}
+		default:
+			t.Fatal("Close() returned before a queued job finished")
+		}
+	}
+	if _, err := d.Submit(context.Background(), newJob(t, senderA, "", 0)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit() after Close() error = %v, want %v", err, ErrClosed)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines after Close() = %d, want at most %d", after, before)
+	}
+}
@@ -0,0 +1,160 @@
+// Package eip7702 reads and manages EIP-7702 delegations of EOAs through an Ethereum RPC backend.
+package eip7702
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// DelegationPrefix is the EIP-7702 delegation designator prefix written to the code of a delegated EOA.
+var DelegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// Backend is the subset of an Ethereum RPC client used by Eip7702, satisfied by *ethclient.Client.
+type Backend interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// DelegationStatus describes the EIP-7702 delegation state of an account.
+type DelegationStatus struct {
+	Nonce      uint64         // Current (pending) account nonce, to be used in a new authorization
+	Delegated  bool           // Whether the account code is a delegation designator
+	Delegate   common.Address // Current delegate, zero when not delegated
+	IsContract bool           // Whether the account holds regular contract code and cannot be delegated
+}
+
+// DelegatesTo reports whether the account is currently delegated to implementation.
+func (s *DelegationStatus) DelegatesTo(implementation common.Address) bool {
+	return s.Delegated && s.Delegate == implementation
+}
+
+// Eip7702 reads EOA nonces and delegation designators to decide when an authorization is needed.
+type Eip7702 struct {
+	backend Backend
+}
+
+// NewEip7702 creates an EIP-7702 helper reading chain state through backend.
+func NewEip7702(backend Backend) *Eip7702 {
+	return &Eip7702{backend: backend}
+}
+
+// ParseDelegation parses account code as a delegation designator (0xef0100 || address).
+// Returns the delegate and true if code is a delegation designator.
+func ParseDelegation(code []byte) (common.Address, bool) {
+	if len(code) != len(DelegationPrefix)+common.AddressLength || !bytes.HasPrefix(code, DelegationPrefix) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(code[len(DelegationPrefix):]), true
+}
+
+// DelegationDesignator returns the code an EOA delegated to delegate holds.
+func DelegationDesignator(delegate common.Address) []byte {
+	return append(append([]byte{}, DelegationPrefix...), delegate.Bytes()...)
+}
+
+// Nonce returns the current (pending) nonce of account.
+func (e *Eip7702) Nonce(ctx context.Context, account common.Address) (uint64, error) {
+	nonce, err := e.backend.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get nonce of %s: %w", account.Hex(), err)
+	}
+	return nonce, nil
+}
+
+// Status returns the nonce and delegation state of account.
+func (e *Eip7702) Status(ctx context.Context, account common.Address) (*DelegationStatus, error) {
+	code, err := e.backend.CodeAt(ctx, account, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code of %s: %w", account.Hex(), err)
+	}
+	nonce, err := e.Nonce(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &DelegationStatus{Nonce: nonce}
+	if delegate, ok := ParseDelegation(code); ok {
+		status.Delegated = true
+		status.Delegate = delegate
+	} else if len(code) > 0 {
+		status.IsContract = true
+	}
+	return status, nil
+}
+
+// Delegate returns the current delegate of account and whether it is delegated at all.
+func (e *Eip7702) Delegate(ctx context.Context, account common.Address) (common.Address, bool, error) {
+	code, err := e.backend.CodeAt(ctx, account, nil)
+	if err != nil {
+		return common.Address{}, false, fmt.Errorf("failed to get code of %s: %w", account.Hex(), err)
+	}
+	delegate, ok := ParseDelegation(code)
+	return delegate, ok, nil
+}
+
+// HashOptions returns the userop hash options of an EntryPoint v0.8 user operation sent by account:
+// its current delegate when it is delegated, since user operations of delegated accounts carry no authorization.
+func (e *Eip7702) HashOptions(ctx context.Context, account common.Address) ([]userop.HashOption, error) {
+	delegate, ok, err := e.Delegate(ctx, account)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return []userop.HashOption{userop.WithDelegate(delegate)}, nil
+}
+
+// AuthorizationFor signs an authorization delegating the signer's EOA to implementation on chainID,
+// using the account's current nonce. Returns nil when the EOA already delegates to implementation.
+func (e *Eip7702) AuthorizationFor(ctx context.Context, s signer.Signer, chainID uint64, implementation string) (*types.SignedAuthorization, error) {
+	if !common.IsHexAddress(implementation) {
+		return nil, fmt.Errorf("invalid implementation address: %q", implementation)
+	}
+
+	status, err := e.Status(ctx, s.Address())
+	if err != nil {
+		return nil, err
+	}
+	if status.IsContract {
+		return nil, fmt.Errorf("account %s is a contract and cannot be delegated", s.Address().Hex())
+	}
+	if status.DelegatesTo(common.HexToAddress(implementation)) {
+		return nil, nil
+	}
+
+	return s.SignAuthorization(ctx, types.Authorization{
+		ChainID: chainID,
+		Address: implementation,
+		Nonce:   status.Nonce,
+	})
+}
+
+// PrepareBuildRequest sets req.Authorization for an EIP-7702 account when it does not yet delegate to the
+// account implementation of req.KernelVersion, and clears it when the delegation is already in place.
+// The resulting user operations are then hashed and signed with HashOptions.
+func (e *Eip7702) PrepareBuildRequest(ctx context.Context, s signer.Signer, chainID uint64, req *types.BuildUserOpRequest) error {
+	if !req.IsEip7702Account {
+		return fmt.Errorf("build request is not for an EIP-7702 account")
+	}
+	if !common.IsHexAddress(req.Account) || common.HexToAddress(req.Account) != s.Address() {
+		return fmt.Errorf("build request account %s does not match signer %s", req.Account, s.Address().Hex())
+	}
+
+	implementation, err := constants.GetAccountImplementationAddress(constants.KernelVersion(req.KernelVersion))
+	if err != nil {
+		return err
+	}
+
+	authorization, err := e.AuthorizationFor(ctx, s, chainID, implementation)
+	if err != nil {
+		return err
+	}
+	req.Authorization = authorization
+	return nil
+}
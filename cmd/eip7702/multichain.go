@@ -0,0 +1,122 @@
+package eip7702
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// ProvisionOptions configures ProvisionAuthorizations.
+type ProvisionOptions struct {
+	// AllowUniversal lets chains where the EOA has the same nonce share a single chain id 0
+	// authorization, so the owner signs once per distinct nonce instead of once per chain.
+	AllowUniversal bool
+	// WarningHandler, when set, is called before signing each chain id 0 authorization.
+	WarningHandler func(ctx context.Context, warning signer.UniversalAuthorizationWarning)
+}
+
+// ProvisionAuthorizations signs the authorizations needed to delegate the signer's EOA to implementation
+// on every chain in backends, keyed by chain ID, using each chain's current nonce. Chains where the EOA
+// already delegates to implementation map to nil.
+//
+// Backends that also expose ChainID are checked to be on the chain they are keyed by.
+func ProvisionAuthorizations(ctx context.Context, s signer.Signer, implementation string, backends map[uint64]Backend, opts *ProvisionOptions) (map[uint64]*types.SignedAuthorization, error) {
+	if !common.IsHexAddress(implementation) {
+		return nil, fmt.Errorf("invalid implementation address: %q", implementation)
+	}
+	if opts == nil {
+		opts = &ProvisionOptions{}
+	}
+
+	chainIDs := make([]uint64, 0, len(backends))
+	for chainID := range backends {
+		if chainID == 0 {
+			return nil, fmt.Errorf("invalid chain id 0")
+		}
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Slice(chainIDs, func(i, j int) bool { return chainIDs[i] < chainIDs[j] })
+
+	authorizations := make(map[uint64]*types.SignedAuthorization, len(backends))
+	chainsByNonce := make(map[uint64][]uint64)
+	var nonces []uint64
+	for _, chainID := range chainIDs {
+		backend := backends[chainID]
+		if err := checkBackendChainID(ctx, backend, chainID); err != nil {
+			return nil, err
+		}
+
+		status, err := NewEip7702(backend).Status(ctx, s.Address())
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: %w", chainID, err)
+		}
+		if status.IsContract {
+			return nil, fmt.Errorf("chain %d: account %s is a contract and cannot be delegated", chainID, s.Address().Hex())
+		}
+		if status.DelegatesTo(common.HexToAddress(implementation)) {
+			authorizations[chainID] = nil
+			continue
+		}
+
+		if _, ok := chainsByNonce[status.Nonce]; !ok {
+			nonces = append(nonces, status.Nonce)
+		}
+		chainsByNonce[status.Nonce] = append(chainsByNonce[status.Nonce], chainID)
+	}
+
+	for _, nonce := range nonces {
+		chains := chainsByNonce[nonce]
+		if opts.AllowUniversal && len(chains) > 1 {
+			var signOpts []signer.UniversalAuthorizationOption
+			if opts.WarningHandler != nil {
+				signOpts = append(signOpts, signer.WithWarningHandler(opts.WarningHandler))
+			}
+			authorization, err := signer.SignUniversalAuthorization(ctx, s, implementation, nonce, signOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sign universal authorization for nonce %d: %w", nonce, err)
+			}
+			for _, chainID := range chains {
+				authorizations[chainID] = authorization
+			}
+			continue
+		}
+
+		for _, chainID := range chains {
+			authorization, err := s.SignAuthorization(ctx, types.Authorization{
+				ChainID: chainID,
+				Address: implementation,
+				Nonce:   nonce,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("chain %d: failed to sign authorization: %w", chainID, err)
+			}
+			authorizations[chainID] = authorization
+		}
+	}
+
+	return authorizations, nil
+}
+
+// checkBackendChainID verifies the chain of backends that can report it.
+func checkBackendChainID(ctx context.Context, backend Backend, chainID uint64) error {
+	chainIDBackend, ok := backend.(interface {
+		ChainID(ctx context.Context) (*big.Int, error)
+	})
+	if !ok {
+		return nil
+	}
+
+	actual, err := chainIDBackend.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("chain %d: failed to get chain id: %w", chainID, err)
+	}
+	if !actual.IsUint64() || actual.Uint64() != chainID {
+		return fmt.Errorf("backend for chain %d is on chain %s", chainID, actual)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package eip7702
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// TxBackend is the subset of an Ethereum RPC client used by SetCodeSender, satisfied by *ethclient.Client.
+type TxBackend interface {
+	Backend
+	ChainID(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error
+}
+
+// SetCodeTxOptions overrides defaults of a SetCode transaction. Zero values use the defaults.
+type SetCodeTxOptions struct {
+	To        *common.Address // Call target, defaults to the relayer itself
+	Data      []byte          // Call data, defaults to empty
+	Value     *big.Int        // Call value, defaults to zero
+	GasLimit  uint64          // Gas limit, estimated when zero
+	GasTipCap *big.Int        // Max priority fee, suggested by the backend when nil
+	GasFeeCap *big.Int        // Max fee, twice the base fee plus the tip when nil
+}
+
+// SetCodeSender builds, signs and broadcasts EIP-7702 type-4 SetCode transactions paid by a relayer.
+type SetCodeSender struct {
+	backend TxBackend
+	relayer signer.Signer
+}
+
+// NewSetCodeSender creates a sender whose transactions are signed and paid for by relayer.
+func NewSetCodeSender(backend TxBackend, relayer signer.Signer) *SetCodeSender {
+	return &SetCodeSender{
+		backend: backend,
+		relayer: relayer,
+	}
+}
+
+// BuildSetCodeTx builds and signs a SetCode transaction carrying the given authorizations.
+// Every authorization is checked to be well formed and valid for the backend's chain.
+func (s *SetCodeSender) BuildSetCodeTx(ctx context.Context, authorizations []*types.SignedAuthorization, opts *SetCodeTxOptions) (*gethtypes.Transaction, error) {
+	if len(authorizations) == 0 {
+		return nil, fmt.Errorf("at least one authorization is required")
+	}
+	if opts == nil {
+		opts = &SetCodeTxOptions{}
+	}
+
+	chainID, err := s.backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain id: %w", err)
+	}
+	relayer := s.relayer.Address()
+	nonce, err := s.backend.PendingNonceAt(ctx, relayer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relayer nonce: %w", err)
+	}
+
+	authList := make([]gethtypes.SetCodeAuthorization, 0, len(authorizations))
+	for i, auth := range authorizations {
+		if auth.ChainID != 0 && new(big.Int).SetUint64(auth.ChainID).Cmp(chainID) != 0 {
+			return nil, fmt.Errorf("authorization %d is for chain %d, backend is on chain %s", i, auth.ChainID, chainID)
+		}
+		authority, err := signer.RecoverAuthorizationSigner(auth)
+		if err != nil {
+			return nil, fmt.Errorf("authorization %d: %w", i, err)
+		}
+		// The relayer's own nonce is incremented by the transaction before authorizations are processed.
+		if authority == relayer && auth.Nonce != nonce+1 {
+			return nil, fmt.Errorf("authorization %d is signed by the relayer and must use nonce %d, got %d", i, nonce+1, auth.Nonce)
+		}
+		setCodeAuth, err := signer.ToSetCodeAuthorization(auth)
+		if err != nil {
+			return nil, fmt.Errorf("authorization %d: %w", i, err)
+		}
+		authList = append(authList, setCodeAuth)
+	}
+
+	to := relayer
+	if opts.To != nil {
+		to = *opts.To
+	}
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	tipCap := opts.GasTipCap
+	if tipCap == nil {
+		if tipCap, err = s.backend.SuggestGasTipCap(ctx); err != nil {
+			return nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+		}
+	}
+	feeCap := opts.GasFeeCap
+	if feeCap == nil {
+		head, err := s.backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest header: %w", err)
+		}
+		if head.BaseFee == nil {
+			return nil, fmt.Errorf("chain does not support EIP-1559 fees")
+		}
+		feeCap = new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tipCap)
+	}
+
+	chainIDValue, err := toUint256("chain id", chainID)
+	if err != nil {
+		return nil, err
+	}
+	tipCapValue, err := toUint256("gas tip cap", tipCap)
+	if err != nil {
+		return nil, err
+	}
+	feeCapValue, err := toUint256("gas fee cap", feeCap)
+	if err != nil {
+		return nil, err
+	}
+	valueValue, err := toUint256("value", value)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit, err = s.backend.EstimateGas(ctx, ethereum.CallMsg{
+			From:              relayer,
+			To:                &to,
+			GasTipCap:         tipCap,
+			GasFeeCap:         feeCap,
+			Value:             value,
+			Data:              opts.Data,
+			AuthorizationList: authList,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+	}
+
+	tx := gethtypes.NewTx(&gethtypes.SetCodeTx{
+		ChainID:   chainIDValue,
+		Nonce:     nonce,
+		GasTipCap: tipCapValue,
+		GasFeeCap: feeCapValue,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     valueValue,
+		Data:      opts.Data,
+		AuthList:  authList,
+	})
+
+	txSigner := gethtypes.NewPragueSigner(chainID)
+	signature, err := s.relayer.SignHash(ctx, txSigner.Hash(tx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+	signedTx, err := tx.WithSignature(txSigner, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach transaction signature: %w", err)
+	}
+	return signedTx, nil
+}
+
+// SendSetCodeTx builds, signs and broadcasts a SetCode transaction carrying the given authorizations.
+// Several EOAs can be migrated at once by passing all their authorizations.
+func (s *SetCodeSender) SendSetCodeTx(ctx context.Context, authorizations []*types.SignedAuthorization, opts *SetCodeTxOptions) (*gethtypes.Transaction, error) {
+	tx, err := s.BuildSetCodeTx(ctx, authorizations, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.backend.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// SignRevocation signs an authorization delegating the signer's EOA to the zero address, which clears
+// its delegation once included. Returns nil when the EOA is not delegated.
+func (e *Eip7702) SignRevocation(ctx context.Context, s signer.Signer, chainID uint64) (*types.SignedAuthorization, error) {
+	status, err := e.Status(ctx, s.Address())
+	if err != nil {
+		return nil, err
+	}
+	if !status.Delegated {
+		return nil, nil
+	}
+
+	return s.SignAuthorization(ctx, types.Authorization{
+		ChainID: chainID,
+		Address: common.Address{}.Hex(),
+		Nonce:   status.Nonce,
+	})
+}
+
+// toUint256 converts a transaction field to a uint256, rejecting negative and overflowing values.
+func toUint256(name string, v *big.Int) (*uint256.Int, error) {
+	if v.Sign() < 0 {
+		return nil, fmt.Errorf("%s must not be negative: %s", name, v)
+	}
+	u, overflow := uint256.FromBig(v)
+	if overflow {
+		return nil, fmt.Errorf("%s overflows 256 bits: %s", name, v)
+	}
+	return u, nil
+}
@@ -0,0 +1,193 @@
+// Package entrypoint decodes ERC-4337 EntryPoint errors and revert data.
+package entrypoint
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// AACodeDescriptions maps EntryPoint AA error codes to human readable descriptions.
+var AACodeDescriptions = map[string]string{
+	"AA10": "sender already constructed",
+	"AA13": "initCode failed or ran out of gas",
+	"AA14": "initCode must return sender",
+	"AA15": "initCode must create sender",
+	"AA20": "account not deployed",
+	"AA21": "account didn't pay prefund",
+	"AA22": "account signature expired or not due",
+	"AA23": "account validation reverted or ran out of gas",
+	"AA24": "account signature error",
+	"AA25": "invalid account nonce",
+	"AA26": "account validation over verificationGasLimit",
+	"AA30": "paymaster not deployed",
+	"AA31": "paymaster deposit too low",
+	"AA32": "paymaster signature expired or not due",
+	"AA33": "paymaster validation reverted or ran out of gas",
+	"AA34": "paymaster signature error",
+	"AA36": "paymaster validation over paymasterVerificationGasLimit",
+	"AA40": "over verificationGasLimit",
+	"AA41": "too little verificationGas",
+	"AA50": "paymaster postOp reverted",
+	"AA51": "prefund below actualGasCost",
+	"AA90": "invalid beneficiary",
+	"AA91": "failed send to beneficiary",
+	"AA92": "internal call only",
+	"AA93": "invalid paymasterAndData",
+	"AA94": "gas values overflow",
+	"AA95": "out of gas",
+	"AA96": "invalid aggregator",
+}
+
+var (
+	stringType, _  = abi.NewType("string", "", nil)
+	uint256Type, _ = abi.NewType("uint256", "", nil)
+	bytesType, _   = abi.NewType("bytes", "", nil)
+	addressType, _ = abi.NewType("address", "", nil)
+	boolType, _    = abi.NewType("bool", "", nil)
+
+	failedOp           = abi.NewError("FailedOp", abi.Arguments{{Type: uint256Type}, {Type: stringType}})
+	failedOpWithRevert = abi.NewError("FailedOpWithRevert", abi.Arguments{{Type: uint256Type}, {Type: stringType}, {Type: bytesType}})
+
+	aaCodePattern   = regexp.MustCompile(`\b(AA[1-9][0-9])\b`)
+	failedOpPattern = regexp.MustCompile(`0x(?:` + fmt.Sprintf("%x|%x", failedOp.ID[:4], failedOpWithRevert.ID[:4]) + `)[0-9a-fA-F]*`)
+	hexDataPattern  = regexp.MustCompile(`0x[0-9a-fA-F]{8,}`)
+)
+
+// EntryPointError represents a decoded FailedOp or FailedOpWithRevert EntryPoint error.
+type EntryPointError struct {
+	OpIndex     int     // Index of the failing operation in the bundle, or -1 when unknown
+	Code        string  // AA error code, e.g. "AA21"
+	Reason      string  // Raw reason string reported by the EntryPoint or bundler
+	Description string  // Human readable description of Code
+	Inner       *Revert // Decoded inner revert, if any
+}
+
+// Error implements the error interface.
+func (e *EntryPointError) Error() string {
+	var b strings.Builder
+	b.WriteString("entrypoint: ")
+	if e.Code != "" {
+		b.WriteString(e.Code)
+		if e.Description != "" {
+			fmt.Fprintf(&b, " (%s)", e.Description)
+		}
+	} else {
+		b.WriteString(e.Reason)
+	}
+	if e.OpIndex >= 0 {
+		fmt.Fprintf(&b, " at op %d", e.OpIndex)
+	}
+	if e.Inner != nil {
+		fmt.Fprintf(&b, ": %s", e.Inner)
+	}
+	return b.String()
+}
+
+// Category returns the component responsible for the error based on its AA code:
+// "factory", "account", "paymaster", "verification", "postOp" or "entrypoint".
+func (e *EntryPointError) Category() string {
+	if len(e.Code) != 4 {
+		return ""
+	}
+	switch e.Code[2] {
+	case '1':
+		return "factory"
+	case '2':
+		return "account"
+	case '3':
+		return "paymaster"
+	case '4':
+		return "verification"
+	case '5':
+		return "postOp"
+	case '9':
+		return "entrypoint"
+	default:
+		return ""
+	}
+}
+
+// DecodeEntryPointError decodes ABI-encoded FailedOp or FailedOpWithRevert revert data.
+func DecodeEntryPointError(data []byte) (*EntryPointError, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("revert data too short: %d bytes", len(data))
+	}
+
+	switch {
+	case bytes.Equal(data[:4], failedOp.ID[:4]):
+		args, err := failedOp.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode FailedOp: %w", err)
+		}
+		return newEntryPointError(args[0].(*big.Int), args[1].(string), nil), nil
+	case bytes.Equal(data[:4], failedOpWithRevert.ID[:4]):
+		args, err := failedOpWithRevert.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode FailedOpWithRevert: %w", err)
+		}
+		return newEntryPointError(args[0].(*big.Int), args[1].(string), DecodeRevert(args[2].([]byte))), nil
+	default:
+		return nil, fmt.Errorf("not an EntryPoint FailedOp error: 0x%x", data[:4])
+	}
+}
+
+// ParseEntryPointError extracts an EntryPointError from a bundler or builder error message.
+// ABI-encoded FailedOp data embedded in the message is preferred; otherwise the first AA code
+// is used and any hex data following it is decoded as the inner revert.
+// Returns false when the message carries no EntryPoint error.
+func ParseEntryPointError(message string) (*EntryPointError, bool) {
+	if match := failedOpPattern.FindString(message); match != "" {
+		if raw, err := hexutil.Decode(match); err == nil {
+			if epErr, err := DecodeEntryPointError(raw); err == nil {
+				return epErr, true
+			}
+		}
+	}
+
+	loc := aaCodePattern.FindStringSubmatchIndex(message)
+	if loc == nil {
+		return nil, false
+	}
+	code := message[loc[2]:loc[3]]
+	rest := message[loc[0]:]
+
+	epErr := &EntryPointError{
+		OpIndex:     -1,
+		Code:        code,
+		Reason:      strings.TrimSpace(rest),
+		Description: AACodeDescriptions[code],
+	}
+	if inner := hexDataPattern.FindString(rest); inner != "" && len(inner)%2 == 0 {
+		epErr.Inner = DecodeRevertHex(inner)
+		epErr.Reason = strings.TrimSpace(strings.Replace(epErr.Reason, inner, "", 1))
+	}
+	return epErr, true
+}
+
+// DecodeReceiptReason decodes the revert reason of a failed user operation receipt.
+// Returns nil when the receipt is successful or carries no reason.
+func DecodeReceiptReason(receipt *types.UserOpReceipt) *Revert {
+	if receipt == nil || receipt.Success || receipt.Reason == "" {
+		return nil
+	}
+	return DecodeRevertHex(receipt.Reason)
+}
+
+func newEntryPointError(opIndex *big.Int, reason string, inner *Revert) *EntryPointError {
+	epErr := &EntryPointError{OpIndex: -1, Reason: reason, Inner: inner}
+	if opIndex.IsInt64() {
+		epErr.OpIndex = int(opIndex.Int64())
+	}
+	if code := aaCodePattern.FindString(reason); code != "" {
+		epErr.Code = code
+		epErr.Description = AACodeDescriptions[code]
+	}
+	return epErr
+}
@@ -0,0 +1,195 @@
+package entrypoint
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// RevertKind classifies decoded revert data.
+type RevertKind int
+
+// Supported revert kinds
+const (
+	RevertUnknown RevertKind = iota // Unrecognized or empty revert data
+	RevertError                     // Error(string)
+	RevertPanic                     // Panic(uint256)
+	RevertCustom                    // A registered custom error
+)
+
+var (
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// panicReasons maps Solidity panic codes to their descriptions.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized function",
+}
+
+var (
+	customErrorsMu sync.RWMutex
+	customErrors   = map[[4]byte]abi.Error{}
+)
+
+func init() {
+	for _, e := range []struct {
+		name string
+		args abi.Arguments
+	}{
+		// EntryPoint
+		{"PostOpReverted", abi.Arguments{{Type: bytesType}}},
+		{"SenderAddressResult", abi.Arguments{{Type: addressType}}},
+		{"SignatureValidationFailed", abi.Arguments{{Type: addressType}}},
+		{"DelegateAndRevert", abi.Arguments{{Type: boolType}, {Type: bytesType}}},
+		// Kernel v3
+		{"ExecutionReverted", nil},
+		{"InvalidExecutor", nil},
+		{"InvalidFallback", nil},
+		{"InvalidCallType", nil},
+		{"OnlyExecuteUserOp", nil},
+		{"InvalidModuleType", nil},
+		{"InvalidCaller", nil},
+		{"InvalidSelector", nil},
+		{"InitConfigError", abi.Arguments{{Type: uint256Type}}},
+		{"AlreadyInitialized", nil},
+		{"InvalidNonce", nil},
+		{"InvalidValidator", nil},
+		{"InvalidValidationType", nil},
+		{"NotSupportedCallType", nil},
+		{"EnableNotApproved", nil},
+		{"PolicyFailed", abi.Arguments{{Type: uint256Type}}},
+		{"PolicySignatureOrderError", nil},
+		{"SignerPrefixNotPresent", nil},
+		{"PolicyDataTooLarge", nil},
+		{"NonceInvalidationError", nil},
+	} {
+		RegisterCustomError(abi.NewError(e.name, e.args))
+	}
+}
+
+// RegisterCustomError adds a custom error definition used by DecodeRevert.
+// Registering an error with an existing selector replaces the previous definition.
+func RegisterCustomError(e abi.Error) {
+	var selector [4]byte
+	copy(selector[:], e.ID[:4])
+
+	customErrorsMu.Lock()
+	defer customErrorsMu.Unlock()
+	customErrors[selector] = e
+}
+
+// Revert represents decoded revert data.
+type Revert struct {
+	Kind      RevertKind
+	Selector  [4]byte       // First four bytes of the revert data, if present
+	Message   string        // Reason for Error(string), or the raw reason when not ABI encoded
+	PanicCode *big.Int      // Code for Panic(uint256)
+	Name      string        // Name of the custom error
+	Args      []interface{} // Decoded arguments of the custom error
+	Data      []byte        // Raw revert data
+}
+
+// String returns a human readable description of the revert.
+func (r *Revert) String() string {
+	switch r.Kind {
+	case RevertError:
+		return fmt.Sprintf("Error(%q)", r.Message)
+	case RevertPanic:
+		desc := "unknown panic"
+		if r.PanicCode.IsUint64() {
+			if reason, ok := panicReasons[r.PanicCode.Uint64()]; ok {
+				desc = reason
+			}
+		}
+		return fmt.Sprintf("Panic(0x%x): %s", r.PanicCode, desc)
+	case RevertCustom:
+		args := make([]string, 0, len(r.Args))
+		for _, arg := range r.Args {
+			args = append(args, formatArg(arg))
+		}
+		return fmt.Sprintf("%s(%s)", r.Name, strings.Join(args, ", "))
+	default:
+		if r.Message != "" {
+			return r.Message
+		}
+		if len(r.Data) == 0 {
+			return "empty revert data"
+		}
+		return "unknown revert " + hexutil.Encode(r.Data)
+	}
+}
+
+// DecodeRevert decodes Error(string), Panic(uint256) and registered custom errors.
+// Unrecognized data is returned with RevertUnknown rather than as an error.
+func DecodeRevert(data []byte) *Revert {
+	r := &Revert{Kind: RevertUnknown, Data: data}
+	if len(data) < 4 {
+		return r
+	}
+	copy(r.Selector[:], data[:4])
+
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		if msg, err := abi.UnpackRevert(data); err == nil {
+			r.Kind = RevertError
+			r.Message = msg
+		}
+	case bytes.Equal(data[:4], panicSelector):
+		if len(data) == 36 {
+			r.Kind = RevertPanic
+			r.PanicCode = new(big.Int).SetBytes(data[4:])
+		}
+	default:
+		customErrorsMu.RLock()
+		e, ok := customErrors[r.Selector]
+		customErrorsMu.RUnlock()
+		if !ok {
+			return r
+		}
+		args, err := e.Inputs.Unpack(data[4:])
+		if err != nil {
+			return r
+		}
+		r.Kind = RevertCustom
+		r.Name = e.Name
+		r.Args = args
+	}
+
+	return r
+}
+
+// DecodeRevertHex decodes 0x-prefixed revert data.
+// Strings that are not hex are returned as a RevertUnknown carrying the string as its message.
+func DecodeRevertHex(data string) *Revert {
+	raw, err := hexutil.Decode(data)
+	if err != nil {
+		return &Revert{Kind: RevertUnknown, Message: data}
+	}
+	return DecodeRevert(raw)
+}
+
+func formatArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case []byte:
+		return hexutil.Encode(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,177 @@
+// Package envelope implements a portable JSON file format for unsigned and signed user operations,
+// so that building and sending can happen online while signing happens on an offline machine.
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// Format identifies envelope files.
+const Format = "zerodev-userop-envelope"
+
+// Version is the envelope format version written by Export.
+const Version = 1
+
+var (
+	// ErrInvalidEnvelope is returned when an envelope is malformed or its fields do not match its user operation.
+	ErrInvalidEnvelope = errors.New("invalid user operation envelope")
+	// ErrUnsigned is returned when importing an envelope that has not been signed.
+	ErrUnsigned = errors.New("user operation envelope is not signed")
+)
+
+// Envelope is a self-describing user operation with everything needed to review and sign it offline.
+// UserOpHash and Calls are derived locally from UserOp and are checked again by every step.
+type Envelope struct {
+	Format            string                     `json:"format"`
+	Version           int                        `json:"version"`
+	ChainID           uint64                     `json:"chainId"`
+	EntryPointVersion string                     `json:"entryPointVersion"`
+	KernelVersion     string                     `json:"kernelVersion"`
+	UserOpHash        string                     `json:"userOpHash"`
+	Calls             []types.Call               `json:"calls"`
+	UserOp            *types.BuildUserOpResponse `json:"userOp"`
+	Signature         string                     `json:"signature,omitempty"`
+}
+
+// Export wraps a builder response into an unsigned envelope. The userOpHash is recomputed locally and
+// must match the builder's, and the callData is decoded so the calls can be reviewed before signing.
+// Delegatecalls are rejected since they cannot be reviewed as plain calls.
+func Export(op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, kernelVersion constants.KernelVersion, chainID uint64) ([]byte, error) {
+	if _, err := constants.GetKernelAddresses(kernelVersion); err != nil {
+		return nil, err
+	}
+	userOpHash, err := userop.VerifyUserOpHash(op, entryPointVersion, chainID)
+	if err != nil {
+		return nil, err
+	}
+	calls, err := kernel.DecodeCalls(op.CallData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode calls: %w", err)
+	}
+
+	unsigned := *op
+	unsigned.Signature = ""
+	e := &Envelope{
+		Format:            Format,
+		Version:           Version,
+		ChainID:           chainID,
+		EntryPointVersion: string(entryPointVersion),
+		KernelVersion:     string(kernelVersion),
+		UserOpHash:        userOpHash.Hex(),
+		Calls:             calls,
+		UserOp:            &unsigned,
+	}
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return marshal(e)
+}
+
+// Parse decodes an envelope and validates it.
+func Parse(data []byte) (*Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Validate checks the format and version of the envelope, and that its userOpHash and calls
+// match the user operation it carries. Returns an error wrapping ErrInvalidEnvelope.
+func (e *Envelope) Validate() error {
+	if e.Format != Format {
+		return fmt.Errorf("%w: unexpected format %q", ErrInvalidEnvelope, e.Format)
+	}
+	if e.Version != Version {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidEnvelope, e.Version)
+	}
+	if e.UserOp == nil {
+		return fmt.Errorf("%w: missing user operation", ErrInvalidEnvelope)
+	}
+	if _, err := constants.GetKernelAddresses(constants.KernelVersion(e.KernelVersion)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	op := *e.UserOp
+	op.UserOpHash = e.UserOpHash
+	if _, err := userop.VerifyUserOpHash(&op, constants.EntryPointVersion(e.EntryPointVersion), e.ChainID); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if e.UserOp.UserOpHash != "" && e.UserOp.UserOpHash != e.UserOpHash {
+		return fmt.Errorf("%w: userOpHash %s does not match envelope hash %s", ErrInvalidEnvelope, e.UserOp.UserOpHash, e.UserOpHash)
+	}
+
+	if err := kernel.VerifyBuildResponse(&types.BuildUserOpRequest{Account: e.UserOp.Sender, Calls: e.Calls}, e.UserOp); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	return nil
+}
+
+// Sign validates an envelope and signs its user operation with s, the owner of the account's
+// ECDSA validator. Returns the signed envelope.
+func Sign(ctx context.Context, data []byte, s signer.Signer) ([]byte, error) {
+	e, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	op := *e.UserOp
+	op.UserOpHash = e.UserOpHash
+	signature, err := signer.SignUserOp(ctx, &op, constants.EntryPointVersion(e.EntryPointVersion), e.ChainID, s)
+	if err != nil {
+		return nil, err
+	}
+	e.Signature = signature
+	return marshal(e)
+}
+
+// SetSignature attaches a signature produced outside of Sign, e.g. by a session key or passkey validator,
+// and returns the signed envelope.
+func (e *Envelope) SetSignature(signature string) ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	e.Signature = signature
+	return marshal(e)
+}
+
+// Import validates a signed envelope and returns the request sending its user operation,
+// along with the chain it is meant for. Returns ErrUnsigned when the envelope has no signature.
+func Import(data []byte) (*types.SendUserOpRequest, uint64, error) {
+	e, err := Parse(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if e.Signature == "" {
+		return nil, 0, ErrUnsigned
+	}
+
+	op := *e.UserOp
+	op.UserOpHash = e.UserOpHash
+	return &types.SendUserOpRequest{
+		BuildUserOpResponse: op,
+		EntryPointVersion:   e.EntryPointVersion,
+		Signature:           e.Signature,
+	}, e.ChainID, nil
+}
+
+// marshal encodes an envelope as indented JSON, for files reviewed by humans.
+func marshal(e *Envelope) ([]byte, error) {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,153 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+const testChainID = 8453
+
+var testCalls = []types.Call{
+	{To: "0x2222222222222222222222222222222222222222", Value: "1000", Data: "0x"},
+	{To: "0x3333333333333333333333333333333333333333", Value: "0", Data: "0x1234"},
+}
+
+// testUserOp returns a builder response performing calls, with its userOpHash.
+func testUserOp(t *testing.T, calls []types.Call) *types.BuildUserOpResponse {
+	t.Helper()
+	callData, err := kernel.EncodeCallData(constants.KernelVersion033, calls)
+	if err != nil {
+		t.Fatalf("EncodeCallData() error = %v", err)
+	}
+	op := &types.BuildUserOpResponse{
+		Sender:               "0x6666666666666666666666666666666666666666",
+		Nonce:                "0x5",
+		CallData:             callData,
+		CallGasLimit:         "100000",
+		VerificationGasLimit: "200000",
+		PreVerificationGas:   "50000",
+		MaxFeePerGas:         "3000000000",
+		MaxPriorityFeePerGas: "1000000000",
+	}
+	hash, err := userop.GetUserOpHash(op, constants.EntryPointVersion07, testChainID)
+	if err != nil {
+		t.Fatalf("GetUserOpHash() error = %v", err)
+	}
+	op.UserOpHash = hash.Hex()
+	return op
+}
+
+// tamper decodes an envelope, applies modify and encodes it again.
+func tamper(t *testing.T, data []byte, modify func(e *Envelope)) []byte {
+	t.Helper()
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	modify(&e)
+	tampered, err := json.Marshal(&e)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return tampered
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	s := signer.NewPrivateKeySigner(key)
+	op := testUserOp(t, testCalls)
+
+	unsigned, err := Export(op, constants.EntryPointVersion07, constants.KernelVersion033, testChainID)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if _, _, err := Import(unsigned); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("Import() of unsigned envelope error = %v, want %v", err, ErrUnsigned)
+	}
+
+	signed, err := Sign(ctx, unsigned, s)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	req, chainID, err := Import(signed)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if chainID != testChainID {
+		t.Errorf("Import() chainID = %d, want %d", chainID, testChainID)
+	}
+	if req.UserOpHash != op.UserOpHash || req.CallData != op.CallData || req.EntryPointVersion != string(constants.EntryPointVersion07) {
+		t.Errorf("Import() = %+v, want the exported user operation", req)
+	}
+	want, err := signer.SignUserOp(ctx, op, constants.EntryPointVersion07, testChainID, s)
+	if err != nil {
+		t.Fatalf("SignUserOp() error = %v", err)
+	}
+	if req.Signature != want {
+		t.Errorf("Import() signature = %s, want %s", req.Signature, want)
+	}
+}
+
+func TestEnvelopeTampered(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	s := signer.NewPrivateKeySigner(key)
+
+	unsigned, err := Export(testUserOp(t, testCalls), constants.EntryPointVersion07, constants.KernelVersion033, testChainID)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	signed, err := Sign(ctx, unsigned, s)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	other := testUserOp(t, testCalls[:1])
+	tests := []struct {
+		name   string
+		modify func(e *Envelope)
+	}{
+		{"callData", func(e *Envelope) { e.UserOp.CallData = other.CallData }},
+		{"callData and userOpHash", func(e *Envelope) {
+			e.UserOp.CallData = other.CallData
+			e.UserOp.UserOpHash = other.UserOpHash
+			e.UserOpHash = other.UserOpHash
+		}},
+		{"nonce", func(e *Envelope) { e.UserOp.Nonce = "0x6" }},
+		{"userOpHash", func(e *Envelope) { e.UserOpHash = other.UserOpHash }},
+		{"user operation userOpHash", func(e *Envelope) { e.UserOp.UserOpHash = other.UserOpHash }},
+		{"calls", func(e *Envelope) { e.Calls[0].Value = "2000" }},
+		{"removed call", func(e *Envelope) { e.Calls = e.Calls[:1] }},
+		{"chainId", func(e *Envelope) { e.ChainID = 1 }},
+		{"entryPointVersion", func(e *Envelope) { e.EntryPointVersion = string(constants.EntryPointVersion08) }},
+		{"kernelVersion", func(e *Envelope) { e.KernelVersion = "0.2.4" }},
+		{"version", func(e *Envelope) { e.Version = Version + 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Sign(ctx, tamper(t, unsigned, tt.modify), s); !errors.Is(err, ErrInvalidEnvelope) {
+				t.Errorf("Sign() error = %v, want %v", err, ErrInvalidEnvelope)
+			}
+			if _, _, err := Import(tamper(t, signed, tt.modify)); !errors.Is(err, ErrInvalidEnvelope) {
+				t.Errorf("Import() error = %v, want %v", err, ErrInvalidEnvelope)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileJournal is a Journal appending every transition as a JSON line to a file, synced to disk before
+// Record returns. Opening the journal replays the file and compacts it to the in-flight user operations.
+type FileJournal struct {
+	*MemoryJournal
+	path string
+	file *os.File
+}
+
+// NewFileJournal opens the journal stored at path, creating it when it does not exist.
+// A partially written last line, left by a crash during Record, is ignored.
+func NewFileJournal(path string) (*FileJournal, error) {
+	j := &FileJournal{
+		MemoryJournal: NewMemoryJournal(),
+		path:          path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	if err := j.replay(data); err != nil {
+		return nil, err
+	}
+	if err := j.compact(); err != nil {
+		return nil, err
+	}
+
+	j.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	j.append = j.write
+	return j, nil
+}
+
+// Close closes the journal file.
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// replay applies the entries of a journal file in order.
+func (j *FileJournal) replay(data []byte) error {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var entry Entry
+		err := json.Unmarshal(line, &entry)
+		if err == nil {
+			err = validate(entry)
+		}
+		if err != nil {
+			if i == len(lines)-1 {
+				// Last line without a newline: the process stopped while writing it.
+				break
+			}
+			return fmt.Errorf("failed to decode journal line %d: %w", i+1, err)
+		}
+		j.apply(entry)
+	}
+	return nil
+}
+
+// compact atomically replaces the journal file with the in-flight entries.
+func (j *FileJournal) compact() error {
+	var buf bytes.Buffer
+	for _, key := range j.order {
+		line, err := json.Marshal(j.entries[key])
+		if err != nil {
+			return fmt.Errorf("failed to encode journal entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("failed to compact journal: %w", err)
+	}
+	return nil
+}
+
+// write appends an entry to the journal file and syncs it.
+func (j *FileJournal) write(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return nil
+}
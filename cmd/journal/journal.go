@@ -0,0 +1,148 @@
+// Package journal records the lifecycle of user operations, so that user operations in flight when a
+// process stops can be tracked again after a restart.
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// State is a lifecycle state of a user operation.
+type State string
+
+// User operation lifecycle states
+const (
+	StateBuilt    State = "built"    // Built by the builder, not signed yet
+	StateSigned   State = "signed"   // Signed, possibly sent
+	StateSent     State = "sent"     // Accepted by the bundler
+	StateIncluded State = "included" // Included on chain and executed successfully
+	StateFailed   State = "failed"   // Rejected by the bundler or signer, or included on chain with a reverted execution
+	StateDropped  State = "dropped"  // Abandoned, e.g. never signed or its nonce used by another user operation
+)
+
+// Final reports whether no further transition is expected from s.
+func (s State) Final() bool {
+	return s == StateIncluded || s == StateFailed || s == StateDropped
+}
+
+// ErrInvalidEntry is returned when recording an entry without a userOpHash, chain or state.
+var ErrInvalidEntry = errors.New("invalid journal entry")
+
+// Entry is the latest recorded state of a user operation, identified by its chain and userOpHash.
+type Entry struct {
+	ChainID           uint64                     `json:"chainId"`
+	UserOpHash        string                     `json:"userOpHash"`
+	State             State                      `json:"state"`
+	EntryPointVersion string                     `json:"entryPointVersion,omitempty"`
+	UserOp            *types.BuildUserOpResponse `json:"userOp,omitempty"`
+	Signature         string                     `json:"signature,omitempty"`
+	Receipt           *types.UserOpReceipt       `json:"receipt,omitempty"`
+	Error             string                     `json:"error,omitempty"`
+	UpdatedAt         time.Time                  `json:"updatedAt"`
+}
+
+// Journal stores user operation lifecycle transitions. Implement it to back the journal with a database.
+type Journal interface {
+	// Record stores a transition. The entry replaces the previous entry of the same chain and userOpHash.
+	Record(ctx context.Context, entry Entry) error
+	// InFlight returns the latest entry of every user operation that has not reached a final state.
+	InFlight(ctx context.Context) ([]Entry, error)
+}
+
+// entryKey identifies a user operation.
+type entryKey struct {
+	chainID    uint64
+	userOpHash string
+}
+
+// MemoryJournal is a Journal keeping in-flight user operations in memory.
+type MemoryJournal struct {
+	append func(Entry) error
+
+	mu      sync.Mutex
+	entries map[entryKey]Entry
+	order   []entryKey
+}
+
+var _ Journal = (*MemoryJournal)(nil)
+var _ Journal = (*FileJournal)(nil)
+
+// NewMemoryJournal creates an empty in-memory Journal.
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{entries: make(map[entryKey]Entry)}
+}
+
+// Record stores a transition, forgetting the user operation once it reaches a final state.
+func (j *MemoryJournal) Record(ctx context.Context, entry Entry) error {
+	if err := validate(entry); err != nil {
+		return err
+	}
+	if entry.UpdatedAt.IsZero() {
+		entry.UpdatedAt = time.Now().UTC()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.append != nil {
+		if err := j.append(entry); err != nil {
+			return err
+		}
+	}
+	j.apply(entry)
+	return nil
+}
+
+// InFlight returns the in-flight user operations in the order they were first recorded.
+func (j *MemoryJournal) InFlight(ctx context.Context) ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]Entry, 0, len(j.order))
+	for _, key := range j.order {
+		entries = append(entries, j.entries[key])
+	}
+	return entries, nil
+}
+
+// apply updates the in-memory state with an entry. Must be called with j.mu held.
+func (j *MemoryJournal) apply(entry Entry) {
+	key := entryKey{chainID: entry.ChainID, userOpHash: strings.ToLower(entry.UserOpHash)}
+	_, known := j.entries[key]
+
+	if entry.State.Final() {
+		if known {
+			delete(j.entries, key)
+			for i := range j.order {
+				if j.order[i] == key {
+					j.order = append(j.order[:i], j.order[i+1:]...)
+					break
+				}
+			}
+		}
+		return
+	}
+
+	if !known {
+		j.order = append(j.order, key)
+	}
+	j.entries[key] = entry
+}
+
+// validate checks the fields identifying an entry.
+func validate(entry Entry) error {
+	if entry.ChainID == 0 || entry.UserOpHash == "" {
+		return fmt.Errorf("%w: chain id and userOpHash are required", ErrInvalidEntry)
+	}
+	switch entry.State {
+	case StateBuilt, StateSigned, StateSent, StateIncluded, StateFailed, StateDropped:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown state %q", ErrInvalidEntry, entry.State)
+	}
+}
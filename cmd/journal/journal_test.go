@@ -0,0 +1,192 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// testEntry returns an entry of a user operation identified by n, in state.
+func testEntry(n byte, state State) Entry {
+	return Entry{
+		ChainID:           8453,
+		UserOpHash:        "0x" + string(bytes.Repeat([]byte{'0' + n}, 64)),
+		State:             state,
+		EntryPointVersion: "0.7",
+		UserOp:            &types.BuildUserOpResponse{Sender: "0x1111111111111111111111111111111111111111", Nonce: "0x1"},
+		Signature:         "0x1234",
+	}
+}
+
+// hashes returns the userOpHashes of entries with their states.
+func hashes(entries []Entry) []string {
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.UserOpHash[:3]+":"+string(entry.State))
+	}
+	return got
+}
+
+func TestMemoryJournal(t *testing.T) {
+	ctx := context.Background()
+	j := NewMemoryJournal()
+
+	for _, entry := range []Entry{
+		testEntry(1, StateBuilt),
+		testEntry(2, StateSigned),
+		testEntry(1, StateSigned),
+		testEntry(3, StateSent),
+		testEntry(2, StateIncluded),
+	} {
+		if err := j.Record(ctx, entry); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	entries, err := j.InFlight(ctx)
+	if err != nil {
+		t.Fatalf("InFlight() error = %v", err)
+	}
+	want := []string{"0x1:signed", "0x3:sent"}
+	if got := hashes(entries); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("InFlight() = %v, want %v", got, want)
+	}
+	if entries[0].UpdatedAt.IsZero() {
+		t.Error("Record() did not set UpdatedAt")
+	}
+}
+
+func TestMemoryJournalInvalidEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{"no chain", Entry{UserOpHash: "0x01", State: StateBuilt}},
+		{"no userOpHash", Entry{ChainID: 1, State: StateBuilt}},
+		{"unknown state", Entry{ChainID: 1, UserOpHash: "0x01", State: "pending"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewMemoryJournal().Record(context.Background(), tt.entry); !errors.Is(err, ErrInvalidEntry) {
+				t.Errorf("Record() error = %v, want %v", err, ErrInvalidEntry)
+			}
+		})
+	}
+}
+
+func TestFileJournalReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	j, err := NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("NewFileJournal() error = %v", err)
+	}
+	for _, entry := range []Entry{
+		testEntry(1, StateBuilt),
+		testEntry(2, StateSigned),
+		testEntry(1, StateSigned),
+		testEntry(3, StateSent),
+		testEntry(2, StateFailed),
+		testEntry(3, StateSent),
+	} {
+		if err := j.Record(ctx, entry); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	j, err = NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("NewFileJournal() error = %v", err)
+	}
+	defer j.Close()
+	entries, err := j.InFlight(ctx)
+	if err != nil {
+		t.Fatalf("InFlight() error = %v", err)
+	}
+	want := []string{"0x1:signed", "0x3:sent"}
+	if got := hashes(entries); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("InFlight() after reopening = %v, want %v", got, want)
+	}
+	if entries[0].UserOp == nil || entries[0].Signature != "0x1234" {
+		t.Errorf("InFlight() after reopening lost the signed user operation: %+v", entries[0])
+	}
+
+	// Opening compacts the file to one line per in-flight user operation.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Errorf("journal file has %d lines after compaction, want 2", lines)
+	}
+}
+
+func TestFileJournalPartialLine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	j, err := NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("NewFileJournal() error = %v", err)
+	}
+	if err := j.Record(ctx, testEntry(1, StateSigned)); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	j.Close()
+
+	// A crash while appending leaves a line without its end.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	f.WriteString(`{"chainId":8453,"userOpHash":"0x22`)
+	f.Close()
+
+	j, err = NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("NewFileJournal() with a partial last line error = %v", err)
+	}
+	if err := j.Record(ctx, testEntry(3, StateSent)); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	j.Close()
+
+	// The partial line was dropped by the compaction, so later records are readable.
+	j, err = NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("NewFileJournal() after recording error = %v", err)
+	}
+	defer j.Close()
+	entries, err := j.InFlight(ctx)
+	if err != nil {
+		t.Fatalf("InFlight() error = %v", err)
+	}
+	want := []string{"0x1:signed", "0x3:sent"}
+	if got := hashes(entries); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("InFlight() = %v, want %v", got, want)
+	}
+}
+
+func TestFileJournalCorruptLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	data := `{"chainId":8453,"userOpHash":"0x11","state":"sent"}` + "\n" +
+		`not json` + "\n" +
+		`{"chainId":8453,"userOpHash":"0x33","state":"sent"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := NewFileJournal(path); err == nil {
+		t.Error("NewFileJournal() succeeded with a corrupt line before the last one")
+	}
+}
@@ -0,0 +1,181 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zerodevapp/sdk-go/cmd/nonce"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// Client is the subset of the UserOp builder API used to resume user operations,
+// satisfied by *useropbuilder.UseropBuilderClient.
+type Client interface {
+	SendUserOp(ctx context.Context, chainID uint64, req *types.SendUserOpRequest) (*types.SendUserOpResponse, error)
+	WaitForUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest, pollInterval time.Duration, timeout time.Duration) (*types.UserOpReceipt, error)
+}
+
+// ResumeOptions configures Resume.
+type ResumeOptions struct {
+	PollInterval time.Duration // Receipt poll interval, zero uses the client default
+	Timeout      time.Duration // Receipt timeout, zero uses the client default
+	Concurrency  int           // Maximum user operations tracked at once by Resume, zero uses 8
+	Nonces       nonce.Backend // Optional, reads on-chain nonces to tell user operations that can no longer be included
+}
+
+// Resume tracks every in-flight user operation of j until it reaches a final state, and returns the entries.
+//   - Built user operations were never signed and are recorded as dropped.
+//   - Signed user operations may or may not have been sent, and are sent again. When the bundler rejects
+//     them, e.g. as already known, they are waited for, and recorded as failed without a receipt only when
+//     the bundler validation rejected them.
+//   - Sent user operations are waited for.
+//
+// A user operation without a receipt within the timeout may still be included later, and stays in flight for
+// the next Resume. It is only recorded as dropped when opts.Nonces shows that its nonce was used by another
+// user operation.
+func Resume(ctx context.Context, j Journal, client Client, opts ResumeOptions) ([]Entry, error) {
+	entries, err := j.InFlight(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load in-flight user operations: %w", err)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	results := make([]Entry, len(entries))
+	errs := make([]error, len(entries))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			results[i], errs[i] = Track(ctx, j, client, entry, opts)
+		}()
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
+
+// Track takes a user operation from its recorded state to a final state, recording every transition in j.
+// When ctx ends while waiting for a receipt, the user operation is left in flight and ctx.Err() is returned.
+// A user operation without a receipt is also left in flight with an error, unless it can no longer be included,
+// see Resume.
+func Track(ctx context.Context, j Journal, client Client, entry Entry, opts ResumeOptions) (Entry, error) {
+	switch entry.State {
+	case StateBuilt:
+		return transition(ctx, j, entry, StateDropped, nil, errors.New("user operation was never signed"))
+
+	case StateSigned:
+		if entry.UserOp == nil || entry.Signature == "" {
+			return transition(ctx, j, entry, StateDropped, nil, errors.New("signed user operation is missing from the journal"))
+		}
+		op := *entry.UserOp
+		op.UserOpHash = entry.UserOpHash
+		_, sendErr := client.SendUserOp(ctx, entry.ChainID, &types.SendUserOpRequest{
+			BuildUserOpResponse: op,
+			EntryPointVersion:   entry.EntryPointVersion,
+			Signature:           entry.Signature,
+		})
+		if sendErr != nil {
+			// The user operation may have been included before the restart, in which case its receipt decides.
+			receipt, err := client.WaitForUserOpReceipt(ctx, entry.ChainID, &types.GetUserOpReceiptRequest{UserOpHash: entry.UserOpHash}, opts.PollInterval, opts.Timeout)
+			if err == nil {
+				return RecordReceipt(ctx, j, entry, receipt)
+			}
+			if ctx.Err() != nil {
+				return entry, ctx.Err()
+			}
+			if errors.Is(sendErr, types.ErrValidationFailed) {
+				return transition(ctx, j, entry, StateFailed, nil, fmt.Errorf("failed to send user operation: %w", sendErr))
+			}
+			return settle(ctx, j, entry, opts, errors.Join(fmt.Errorf("failed to send user operation: %w", sendErr), err))
+		}
+		var err error
+		if entry, err = transition(ctx, j, entry, StateSent, nil, nil); err != nil {
+			return entry, err
+		}
+	}
+
+	if entry.State.Final() {
+		return entry, nil
+	}
+	receipt, err := client.WaitForUserOpReceipt(ctx, entry.ChainID, &types.GetUserOpReceiptRequest{UserOpHash: entry.UserOpHash}, opts.PollInterval, opts.Timeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			return entry, ctx.Err()
+		}
+		return settle(ctx, j, entry, opts, err)
+	}
+	return RecordReceipt(ctx, j, entry, receipt)
+}
+
+// settle handles a user operation without a receipt after cause. It is recorded as dropped when its nonce was
+// used by another user operation, and otherwise left in flight with an error, since it may still be included.
+func settle(ctx context.Context, j Journal, entry Entry, opts ResumeOptions, cause error) (Entry, error) {
+	superseded, err := superseded(ctx, entry, opts.Nonces)
+	if err != nil {
+		return entry, errors.Join(cause, err)
+	}
+	if superseded {
+		return transition(ctx, j, entry, StateDropped, nil, fmt.Errorf("nonce was used by another user operation: %w", cause))
+	}
+	return entry, fmt.Errorf("user operation %s is still in flight: %w", entry.UserOpHash, cause)
+}
+
+// superseded reports whether the on-chain nonce of the entry's sender moved past the nonce of its user operation,
+// which then can no longer be included. Reports false without a backend.
+func superseded(ctx context.Context, entry Entry, backend nonce.Backend) (bool, error) {
+	if backend == nil || entry.UserOp == nil {
+		return false, nil
+	}
+	if !common.IsHexAddress(entry.UserOp.Sender) {
+		return false, fmt.Errorf("invalid sender address: %q", entry.UserOp.Sender)
+	}
+	opNonce, err := userop.ParseUint256(entry.UserOp.Nonce)
+	if err != nil {
+		return false, fmt.Errorf("invalid nonce: %w", err)
+	}
+
+	key, sequence := nonce.Decode(opNonce)
+	current, err := backend.GetNonce(ctx, common.HexToAddress(entry.UserOp.Sender), key)
+	if err != nil {
+		return false, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	_, currentSequence := nonce.Decode(current)
+	return currentSequence > sequence, nil
+}
+
+// RecordReceipt records a receipt of a user operation: included when its execution succeeded, failed otherwise.
+func RecordReceipt(ctx context.Context, j Journal, entry Entry, receipt *types.UserOpReceipt) (Entry, error) {
+	if !receipt.Success {
+		return transition(ctx, j, entry, StateFailed, receipt, fmt.Errorf("user operation reverted: %s", receipt.Reason))
+	}
+	return transition(ctx, j, entry, StateIncluded, receipt, nil)
+}
+
+// transition records entry in a new state.
+func transition(ctx context.Context, j Journal, entry Entry, state State, receipt *types.UserOpReceipt, cause error) (Entry, error) {
+	entry.State = state
+	entry.Receipt = receipt
+	entry.Error = ""
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+	entry.UpdatedAt = time.Now().UTC()
+	if err := j.Record(ctx, entry); err != nil {
+		return entry, fmt.Errorf("failed to record user operation %s as %s: %w", entry.UserOpHash, state, err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,186 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zerodevapp/sdk-go/cmd/nonce"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// fakeClient answers sends with sendErr and receipt waits with receipt or waitErr, honoring ctx.
+type fakeClient struct {
+	sendErr error
+	receipt *types.UserOpReceipt
+	waitErr error
+	delay   time.Duration // Time spent waiting for a receipt
+
+	mu         sync.Mutex
+	sent       int
+	waiting    int
+	maxWaiting int
+}
+
+func (c *fakeClient) SendUserOp(ctx context.Context, chainID uint64, req *types.SendUserOpRequest) (*types.SendUserOpResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.sent++
+	c.mu.Unlock()
+	if c.sendErr != nil {
+		return nil, c.sendErr
+	}
+	return &types.SendUserOpResponse{UserOpHash: req.UserOpHash}, nil
+}
+
+func (c *fakeClient) WaitForUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest, pollInterval time.Duration, timeout time.Duration) (*types.UserOpReceipt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.waiting++
+	c.maxWaiting = max(c.maxWaiting, c.waiting)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.waiting--
+		c.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(c.delay):
+	}
+	if c.waitErr != nil {
+		return nil, c.waitErr
+	}
+	receipt := *c.receipt
+	receipt.UserOpHash = req.UserOpHash
+	return &receipt, nil
+}
+
+// fakeNonces reports its value as the sequence of every account and key.
+type fakeNonces uint64
+
+func (n fakeNonces) GetNonce(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error) {
+	return nonce.Encode(key, uint64(n))
+}
+
+func TestTrack(t *testing.T) {
+	errSend := errors.New("already known")
+	errRejected := fmt.Errorf("AA23 reverted: %w", types.ErrValidationFailed)
+	errTimeout := errors.New("timed out waiting for user operation receipt")
+	success := &types.UserOpReceipt{Success: true}
+	reverted := &types.UserOpReceipt{Success: false, Reason: "0x"}
+
+	missingOp := testEntry(1, StateSigned)
+	missingOp.UserOp = nil
+
+	tests := []struct {
+		name      string
+		entry     Entry
+		client    *fakeClient
+		cancelled bool
+		nonces    nonce.Backend
+		wantState State
+		wantSent  int
+		wantErr   error
+	}{
+		{"built", testEntry(1, StateBuilt), &fakeClient{receipt: success}, false, nil, StateDropped, 0, nil},
+		{"signed without user operation", missingOp, &fakeClient{receipt: success}, false, nil, StateDropped, 0, nil},
+		{"signed", testEntry(1, StateSigned), &fakeClient{receipt: success}, false, nil, StateIncluded, 1, nil},
+		{"signed and already included", testEntry(1, StateSigned), &fakeClient{sendErr: errSend, receipt: success}, false, nil, StateIncluded, 1, nil},
+		{"signed and rejected", testEntry(1, StateSigned), &fakeClient{sendErr: errRejected, waitErr: errTimeout}, false, nil, StateFailed, 1, nil},
+		{"signed without receipt", testEntry(1, StateSigned), &fakeClient{sendErr: errSend, waitErr: errTimeout}, false, fakeNonces(1), StateSigned, 1, errTimeout},
+		{"signed and superseded", testEntry(1, StateSigned), &fakeClient{sendErr: errSend, waitErr: errTimeout}, false, fakeNonces(2), StateDropped, 1, nil},
+		{"signed and cancelled", testEntry(1, StateSigned), &fakeClient{sendErr: errSend, receipt: success}, true, nil, StateSigned, 0, context.Canceled},
+		{"sent", testEntry(1, StateSent), &fakeClient{receipt: success}, false, nil, StateIncluded, 0, nil},
+		{"sent and reverted", testEntry(1, StateSent), &fakeClient{receipt: reverted}, false, nil, StateFailed, 0, nil},
+		{"sent without receipt", testEntry(1, StateSent), &fakeClient{waitErr: errTimeout}, false, nil, StateSent, 0, errTimeout},
+		{"sent without receipt and unused nonce", testEntry(1, StateSent), &fakeClient{waitErr: errTimeout}, false, fakeNonces(1), StateSent, 0, errTimeout},
+		{"sent and superseded", testEntry(1, StateSent), &fakeClient{waitErr: errTimeout}, false, fakeNonces(2), StateDropped, 0, nil},
+		{"sent and cancelled", testEntry(1, StateSent), &fakeClient{receipt: success}, true, nil, StateSent, 0, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewMemoryJournal()
+			if err := j.Record(context.Background(), tt.entry); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			got, err := Track(ctx, j, tt.client, tt.entry, ResumeOptions{Nonces: tt.nonces})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Track() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.State != tt.wantState {
+				t.Errorf("Track() state = %s, want %s", got.State, tt.wantState)
+			}
+			if tt.client.sent != tt.wantSent {
+				t.Errorf("Track() sent %d times, want %d", tt.client.sent, tt.wantSent)
+			}
+
+			// Final user operations leave the journal, others stay in flight in their state.
+			entries, err := j.InFlight(context.Background())
+			if err != nil {
+				t.Fatalf("InFlight() error = %v", err)
+			}
+			if tt.wantState.Final() && len(entries) != 0 {
+				t.Errorf("InFlight() = %v, want none", hashes(entries))
+			}
+			if !tt.wantState.Final() && (len(entries) != 1 || entries[0].State != tt.wantState) {
+				t.Errorf("InFlight() = %v, want one %s entry", hashes(entries), tt.wantState)
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	ctx := context.Background()
+	j := NewMemoryJournal()
+	for n := range byte(8) {
+		state := StateSent
+		if n%2 == 0 {
+			state = StateSigned
+		}
+		if err := j.Record(ctx, testEntry(n+1, state)); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	client := &fakeClient{receipt: &types.UserOpReceipt{Success: true}, delay: 20 * time.Millisecond}
+
+	results, err := Resume(ctx, j, client, ResumeOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if len(results) != 8 {
+		t.Fatalf("Resume() returned %d entries, want 8", len(results))
+	}
+	for _, result := range results {
+		if result.State != StateIncluded {
+			t.Errorf("Resume() state of %s = %s, want %s", result.UserOpHash, result.State, StateIncluded)
+		}
+	}
+	if client.sent != 4 {
+		t.Errorf("Resume() sent %d user operations, want 4", client.sent)
+	}
+	if client.maxWaiting > 2 {
+		t.Errorf("Resume() tracked %d user operations at once, want at most 2", client.maxWaiting)
+	}
+	if entries, _ := j.InFlight(ctx); len(entries) != 0 {
+		t.Errorf("InFlight() after Resume() = %v, want none", hashes(entries))
+	}
+}
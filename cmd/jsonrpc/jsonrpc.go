@@ -0,0 +1,154 @@
+// Package jsonrpc implements a minimal JSON-RPC 2.0 client over HTTP for bundler and paymaster services.
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/zerodevapp/sdk-go/cmd/entrypoint"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// Error represents a JSON-RPC error returned by the server.
+type Error struct {
+	Method  string          // JSON-RPC method that failed
+	Code    int             // JSON-RPC error code
+	Message string          // JSON-RPC error message
+	Data    json.RawMessage // Additional error data, if any
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: rpc error %d: %s", e.Method, e.Code, e.Message)
+}
+
+// Unwrap returns types.ErrValidationFailed for ERC-4337 rejection codes (-32500 to -32507)
+// and the decoded EntryPoint error, if any, so errors.Is and errors.As work on Error.
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Code <= -32500 && e.Code >= -32507 {
+		errs = append(errs, types.ErrValidationFailed)
+	}
+	if epErr := e.EntryPointError(); epErr != nil {
+		errs = append(errs, epErr)
+	}
+	return errs
+}
+
+// EntryPointError returns the EntryPoint FailedOp error carried in the message or data, if any.
+func (e *Error) EntryPointError() *entrypoint.EntryPointError {
+	for _, s := range []string{e.Message, string(e.Data)} {
+		if epErr, ok := entrypoint.ParseEntryPointError(s); ok {
+			return epErr
+		}
+	}
+	return nil
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	} `json:"error"`
+}
+
+// Client is a minimal JSON-RPC 2.0 client over HTTP.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	headers    http.Header
+	nextID     atomic.Uint64
+}
+
+// NewClient creates a JSON-RPC client for url. headers are sent with every request and may be nil.
+func NewClient(url string, httpClient *http.Client, headers http.Header) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		url:        url,
+		httpClient: httpClient,
+		headers:    headers.Clone(),
+	}
+}
+
+// Call invokes method with params and decodes the result into result, which may be nil.
+func (c *Client) Call(ctx context.Context, result any, method string, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range c.headers {
+		for _, value := range values {
+			httpReq.Header.Add(name, value)
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(bodyBytes, &rpcResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: unexpected status code %d: %s", method, resp.StatusCode, string(bodyBytes))
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return &Error{
+			Method:  method,
+			Code:    rpcResp.Error.Code,
+			Message: rpcResp.Error.Message,
+			Data:    rpcResp.Error.Data,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status code %d: %s", method, resp.StatusCode, string(bodyBytes))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
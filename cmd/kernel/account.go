@@ -0,0 +1,170 @@
+package kernel
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+)
+
+// ValidatorType is the Kernel v3 validation type prefixed to a validator identifier.
+type ValidatorType byte
+
+// Supported Kernel v3 validation types
+const (
+	ValidatorTypeRoot       ValidatorType = 0x00
+	ValidatorTypeValidator  ValidatorType = 0x01
+	ValidatorTypePermission ValidatorType = 0x02
+)
+
+var (
+	addressType, _            = abi.NewType("address", "", nil)
+	bytes21Type, _            = abi.NewType("bytes21", "", nil)
+	bytesArrayType, _         = abi.NewType("bytes[]", "", nil)
+	initializeSelector        = crypto.Keccak256([]byte("initialize(bytes21,address,bytes,bytes,bytes[])"))[:4]
+	initializeArgs            = abi.Arguments{{Type: bytes21Type}, {Type: addressType}, {Type: bytesType}, {Type: bytesType}, {Type: bytesArrayType}}
+	deployWithFactorySelector = crypto.Keccak256([]byte("deployWithFactory(address,bytes,bytes32)"))[:4]
+	deployWithFactoryArgs     = abi.Arguments{{Type: addressType}, {Type: bytesType}, {Type: bytes32Type}}
+)
+
+// ValidatorID returns the 21-byte Kernel validator identifier (type || address).
+func ValidatorID(validatorType ValidatorType, validator common.Address) [21]byte {
+	var id [21]byte
+	id[0] = byte(validatorType)
+	copy(id[1:], validator.Bytes())
+	return id
+}
+
+// GetInitData returns the Kernel initialize callData for a root validator and its install data.
+func GetInitData(version constants.KernelVersion, rootValidator [21]byte, validatorData []byte) ([]byte, error) {
+	if _, err := constants.GetKernelAddresses(version); err != nil {
+		return nil, err
+	}
+
+	encoded, err := initializeArgs.Pack(rootValidator, common.Address{}, validatorData, []byte{}, [][]byte{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode initialize arguments: %w", err)
+	}
+	return append(append([]byte{}, initializeSelector...), encoded...), nil
+}
+
+// GetECDSAInitData returns the Kernel initialize callData for an ECDSA-style validator owned by owner.
+func GetECDSAInitData(version constants.KernelVersion, validator string, owner string) ([]byte, error) {
+	if !common.IsHexAddress(validator) {
+		return nil, fmt.Errorf("invalid validator address: %q", validator)
+	}
+	if !common.IsHexAddress(owner) {
+		return nil, fmt.Errorf("invalid owner address: %q", owner)
+	}
+
+	rootValidator := ValidatorID(ValidatorTypeValidator, common.HexToAddress(validator))
+	return GetInitData(version, rootValidator, common.HexToAddress(owner).Bytes())
+}
+
+// ComputeAccountAddress derives the counterfactual Kernel address for the given initialize callData and index.
+func ComputeAccountAddress(version constants.KernelVersion, initData []byte, index *big.Int) (common.Address, error) {
+	addresses, err := constants.GetKernelAddresses(version)
+	if err != nil {
+		return common.Address{}, err
+	}
+	salt, err := indexToSalt(index)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	// KernelFactory.createAccount salts CREATE2 with keccak256(abi.encodePacked(data, salt)).
+	actualSalt := crypto.Keccak256Hash(initData, salt[:])
+	factory := common.HexToAddress(addresses.FactoryAddress)
+	initCodeHash := common.HexToHash(addresses.InitCodeHash)
+
+	return crypto.CreateAddress2(factory, actualSalt, initCodeHash.Bytes()), nil
+}
+
+// ComputeKernelAddress derives the counterfactual address of a Kernel account owned by owner through validator.
+func ComputeKernelAddress(version constants.KernelVersion, validator string, owner string, index *big.Int) (common.Address, error) {
+	initData, err := GetECDSAInitData(version, validator, owner)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return ComputeAccountAddress(version, initData, index)
+}
+
+// GetFactoryData returns the Factory and FactoryData pair the EntryPoint uses to deploy the account.
+// Deployment goes through the meta factory's deployWithFactory, as the builder service does.
+func GetFactoryData(version constants.KernelVersion, initData []byte, index *big.Int) (string, string, error) {
+	addresses, err := constants.GetKernelAddresses(version)
+	if err != nil {
+		return "", "", err
+	}
+	salt, err := indexToSalt(index)
+	if err != nil {
+		return "", "", err
+	}
+
+	encoded, err := deployWithFactoryArgs.Pack(common.HexToAddress(addresses.FactoryAddress), initData, salt)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode deployWithFactory arguments: %w", err)
+	}
+	factoryData := append(append([]byte{}, deployWithFactorySelector...), encoded...)
+
+	return addresses.MetaFactoryAddress, hexutil.Encode(factoryData), nil
+}
+
+// GetKernelFactoryData returns the Factory and FactoryData pair for a Kernel account owned by owner through validator.
+func GetKernelFactoryData(version constants.KernelVersion, validator string, owner string, index *big.Int) (string, string, error) {
+	initData, err := GetECDSAInitData(version, validator, owner)
+	if err != nil {
+		return "", "", err
+	}
+	return GetFactoryData(version, initData, index)
+}
+
+// ParseInitCode decodes initCode, the meta factory address followed by its deployWithFactory callData as
+// returned by GetFactoryData, and returns the Kernel version of its factory and the address of the deployed account.
+func ParseInitCode(initCode []byte) (constants.KernelVersion, common.Address, error) {
+	if len(initCode) < common.AddressLength+4 {
+		return "", common.Address{}, fmt.Errorf("initCode too short: %d bytes", len(initCode))
+	}
+	metaFactory := common.BytesToAddress(initCode[:common.AddressLength])
+	callData := initCode[common.AddressLength:]
+	if !bytes.Equal(callData[:4], deployWithFactorySelector) {
+		return "", common.Address{}, fmt.Errorf("initCode does not call deployWithFactory")
+	}
+	values, err := deployWithFactoryArgs.Unpack(callData[4:])
+	if err != nil {
+		return "", common.Address{}, fmt.Errorf("failed to decode deployWithFactory arguments: %w", err)
+	}
+	factory, initData, salt := values[0].(common.Address), values[1].([]byte), values[2].([32]byte)
+
+	for version, addresses := range constants.KernelVersionToAddressesMap {
+		if common.HexToAddress(addresses.FactoryAddress) != factory {
+			continue
+		}
+		if common.HexToAddress(addresses.MetaFactoryAddress) != metaFactory {
+			return "", common.Address{}, fmt.Errorf("unknown meta factory %s", metaFactory.Hex())
+		}
+		account, err := ComputeAccountAddress(version, initData, new(big.Int).SetBytes(salt[:]))
+		if err != nil {
+			return "", common.Address{}, err
+		}
+		return version, account, nil
+	}
+	return "", common.Address{}, fmt.Errorf("unknown Kernel factory %s", factory.Hex())
+}
+
+func indexToSalt(index *big.Int) ([32]byte, error) {
+	var salt [32]byte
+	if index == nil {
+		return salt, nil
+	}
+	if index.Sign() < 0 || index.BitLen() > 256 {
+		return salt, fmt.Errorf("index out of range: %s", index)
+	}
+	index.FillBytes(salt[:])
+	return salt, nil
+}
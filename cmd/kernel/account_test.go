@@ -0,0 +1,78 @@
+package kernel
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+)
+
+// The expected addresses were computed with an independent implementation of KernelFactory.getAddress:
+// CREATE2 from the version's factory, salted with keccak256(abi.encodePacked(initData, index)), over the
+// version's init code hash, with initData initializing the ECDSA validator as root validator.
+func TestComputeKernelAddress(t *testing.T) {
+	tests := []struct {
+		version constants.KernelVersion
+		owner   string
+		index   int64
+		want    string
+	}{
+		{constants.KernelVersion031, "0x4444444444444444444444444444444444444444", 0, "0xaf036daa5e82221f96f5c992bd9ba0e62c70aa02"},
+		{constants.KernelVersion031, "0x8ba1f109551bD432803012645Ac136ddd64DBA72", 7, "0x39a31d5aca64f995b523f048d01013a278bbfb06"},
+		{constants.KernelVersion032, "0x4444444444444444444444444444444444444444", 0, "0x19e04ebb1b22f875c0bcddd4cb3b69056217c290"},
+		{constants.KernelVersion032, "0x8ba1f109551bD432803012645Ac136ddd64DBA72", 7, "0xe99ae4c0a4b5172d34484fa6a4e36952c2464b11"},
+		{constants.KernelVersion033, "0x4444444444444444444444444444444444444444", 0, "0x636f56a4c1876db7b03244477b692fa86d80d83e"},
+		{constants.KernelVersion033, "0x8ba1f109551bD432803012645Ac136ddd64DBA72", 7, "0xda36f0658d3584b7bf8e25b315a2b9f6f925518d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s index %d", tt.version, tt.index), func(t *testing.T) {
+			index := big.NewInt(tt.index)
+			got, err := ComputeKernelAddress(tt.version, constants.ECDSAValidatorAddress, tt.owner, index)
+			if err != nil {
+				t.Fatalf("ComputeKernelAddress() error = %v", err)
+			}
+			if want := common.HexToAddress(tt.want); got != want {
+				t.Errorf("ComputeKernelAddress() = %s, want %s", got.Hex(), want.Hex())
+			}
+
+			// The initCode deploying the account resolves to the same address.
+			factory, factoryData, err := GetKernelFactoryData(tt.version, constants.ECDSAValidatorAddress, tt.owner, index)
+			if err != nil {
+				t.Fatalf("GetKernelFactoryData() error = %v", err)
+			}
+			initCode := append(common.HexToAddress(factory).Bytes(), hexutil.MustDecode(factoryData)...)
+			version, account, err := ParseInitCode(initCode)
+			if err != nil {
+				t.Fatalf("ParseInitCode() error = %v", err)
+			}
+			if version != tt.version || account != got {
+				t.Errorf("ParseInitCode() = %s, %s, want %s, %s", version, account.Hex(), tt.version, got.Hex())
+			}
+		})
+	}
+}
+
+func TestComputeKernelAddressErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		version constants.KernelVersion
+		owner   string
+		index   *big.Int
+	}{
+		{"unknown version", "0.2.4", "0x4444444444444444444444444444444444444444", nil},
+		{"invalid owner", constants.KernelVersion033, "0x4444", nil},
+		{"negative index", constants.KernelVersion033, "0x4444444444444444444444444444444444444444", big.NewInt(-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ComputeKernelAddress(tt.version, constants.ECDSAValidatorAddress, tt.owner, tt.index); err == nil {
+				t.Error("ComputeKernelAddress() succeeded, want error")
+			}
+		})
+	}
+}
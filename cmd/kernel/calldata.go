@@ -0,0 +1,325 @@
+// Package kernel implements client-side encoding and decoding for ZeroDev Kernel v3 smart accounts.
+package kernel
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// CallType is the ERC-7579 call type stored in the first byte of the execution mode.
+type CallType byte
+
+// Supported ERC-7579 call types
+const (
+	CallTypeSingle       CallType = 0x00
+	CallTypeBatch        CallType = 0x01
+	CallTypeDelegateCall CallType = 0xff
+)
+
+// ExecType is the ERC-7579 exec type stored in the second byte of the execution mode.
+type ExecType byte
+
+// Supported ERC-7579 exec types
+const (
+	ExecTypeDefault ExecType = 0x00
+	ExecTypeTry     ExecType = 0x01
+)
+
+// ErrCallsMismatch is returned when builder-returned callData does not match the requested calls.
+var ErrCallsMismatch = errors.New("callData does not match requested calls")
+
+// executeSelector is the selector of Kernel v3 execute(bytes32 mode, bytes executionCalldata).
+var executeSelector = crypto.Keccak256([]byte("execute(bytes32,bytes)"))[:4]
+
+var (
+	bytes32Type, _    = abi.NewType("bytes32", "", nil)
+	bytesType, _      = abi.NewType("bytes", "", nil)
+	executionsType, _ = abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "target", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "callData", Type: "bytes"},
+	})
+
+	executeArgs    = abi.Arguments{{Type: bytes32Type}, {Type: bytesType}}
+	executionsArgs = abi.Arguments{{Type: executionsType}}
+)
+
+// execution mirrors the ERC-7579 Execution struct used in batch mode.
+type execution struct {
+	Target   common.Address
+	Value    *big.Int
+	CallData []byte
+}
+
+// ExecMode represents a decoded ERC-7579 execution mode.
+type ExecMode struct {
+	CallType     CallType
+	ExecType     ExecType
+	ModeSelector [4]byte
+	ModePayload  [22]byte
+}
+
+// Bytes returns the 32-byte encoding of the execution mode.
+func (m ExecMode) Bytes() [32]byte {
+	var out [32]byte
+	out[0] = byte(m.CallType)
+	out[1] = byte(m.ExecType)
+	copy(out[6:10], m.ModeSelector[:])
+	copy(out[10:], m.ModePayload[:])
+	return out
+}
+
+// ParseExecMode decodes a 32-byte ERC-7579 execution mode.
+func ParseExecMode(mode [32]byte) ExecMode {
+	m := ExecMode{
+		CallType: CallType(mode[0]),
+		ExecType: ExecType(mode[1]),
+	}
+	copy(m.ModeSelector[:], mode[6:10])
+	copy(m.ModePayload[:], mode[10:])
+	return m
+}
+
+// EncodeCallData encodes calls into Kernel v3 execute callData.
+// A single call uses single mode and multiple calls use batch mode, both with the default exec type.
+func EncodeCallData(version constants.KernelVersion, calls []types.Call) (string, error) {
+	mode := ExecMode{CallType: CallTypeSingle}
+	if len(calls) > 1 {
+		mode.CallType = CallTypeBatch
+	}
+	return EncodeExecute(version, mode, calls)
+}
+
+// EncodeExecute encodes calls into Kernel v3 execute callData using the given execution mode.
+// Single and delegatecall modes require exactly one call, and delegatecalls cannot carry value.
+func EncodeExecute(version constants.KernelVersion, mode ExecMode, calls []types.Call) (string, error) {
+	if _, err := constants.GetKernelAddresses(version); err != nil {
+		return "", err
+	}
+	if len(calls) == 0 {
+		return "", fmt.Errorf("at least one call is required")
+	}
+	if mode.ExecType != ExecTypeDefault && mode.ExecType != ExecTypeTry {
+		return "", fmt.Errorf("unsupported exec type: 0x%02x", byte(mode.ExecType))
+	}
+
+	executions := make([]execution, 0, len(calls))
+	for i, call := range calls {
+		e, err := parseCall(call)
+		if err != nil {
+			return "", fmt.Errorf("invalid call %d: %w", i, err)
+		}
+		executions = append(executions, e)
+	}
+
+	var executionCalldata []byte
+	switch mode.CallType {
+	case CallTypeSingle:
+		if len(executions) != 1 {
+			return "", fmt.Errorf("single mode requires exactly one call, got %d", len(executions))
+		}
+		e := executions[0]
+		executionCalldata = append(e.Target.Bytes(), common.LeftPadBytes(e.Value.Bytes(), 32)...)
+		executionCalldata = append(executionCalldata, e.CallData...)
+	case CallTypeBatch:
+		packed, err := executionsArgs.Pack(executions)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode batch executions: %w", err)
+		}
+		executionCalldata = packed
+	case CallTypeDelegateCall:
+		if len(executions) != 1 {
+			return "", fmt.Errorf("delegatecall mode requires exactly one call, got %d", len(executions))
+		}
+		e := executions[0]
+		if e.Value.Sign() != 0 {
+			return "", fmt.Errorf("delegatecall cannot carry value")
+		}
+		executionCalldata = append(e.Target.Bytes(), e.CallData...)
+	default:
+		return "", fmt.Errorf("unsupported call type: 0x%02x", byte(mode.CallType))
+	}
+
+	encoded, err := executeArgs.Pack(mode.Bytes(), executionCalldata)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode execute arguments: %w", err)
+	}
+
+	return hexutil.Encode(append(append([]byte{}, executeSelector...), encoded...)), nil
+}
+
+// DecodeCallData decodes Kernel v3 execute callData into its execution mode and calls.
+// Delegatecalls are returned with a zero value.
+func DecodeCallData(callData string) (ExecMode, []types.Call, error) {
+	raw, err := hexutil.Decode(callData)
+	if err != nil {
+		return ExecMode{}, nil, fmt.Errorf("invalid callData: %w", err)
+	}
+	if len(raw) < 4 || !bytes.Equal(raw[:4], executeSelector) {
+		return ExecMode{}, nil, fmt.Errorf("callData is not a Kernel execute call")
+	}
+
+	args, err := executeArgs.Unpack(raw[4:])
+	if err != nil {
+		return ExecMode{}, nil, fmt.Errorf("failed to decode execute arguments: %w", err)
+	}
+	mode := ParseExecMode(args[0].([32]byte))
+	executionCalldata := args[1].([]byte)
+
+	if mode.ExecType != ExecTypeDefault && mode.ExecType != ExecTypeTry {
+		return mode, nil, fmt.Errorf("unsupported exec type: 0x%02x", byte(mode.ExecType))
+	}
+
+	var calls []types.Call
+	switch mode.CallType {
+	case CallTypeSingle:
+		if len(executionCalldata) < 52 {
+			return mode, nil, fmt.Errorf("single execution calldata too short: %d bytes", len(executionCalldata))
+		}
+		calls = []types.Call{newCall(
+			common.BytesToAddress(executionCalldata[:20]),
+			new(big.Int).SetBytes(executionCalldata[20:52]),
+			executionCalldata[52:],
+		)}
+	case CallTypeBatch:
+		unpacked, err := executionsArgs.Unpack(executionCalldata)
+		if err != nil {
+			return mode, nil, fmt.Errorf("failed to decode batch executions: %w", err)
+		}
+		executions := *abi.ConvertType(unpacked[0], new([]execution)).(*[]execution)
+		calls = make([]types.Call, 0, len(executions))
+		for _, e := range executions {
+			calls = append(calls, newCall(e.Target, e.Value, e.CallData))
+		}
+	case CallTypeDelegateCall:
+		if len(executionCalldata) < 20 {
+			return mode, nil, fmt.Errorf("delegatecall execution calldata too short: %d bytes", len(executionCalldata))
+		}
+		calls = []types.Call{newCall(common.BytesToAddress(executionCalldata[:20]), new(big.Int), executionCalldata[20:])}
+	default:
+		return mode, nil, fmt.Errorf("unsupported call type: 0x%02x", byte(mode.CallType))
+	}
+
+	return mode, calls, nil
+}
+
+// DecodeCalls decodes Kernel v3 execute callData into the calls it performs.
+func DecodeCalls(callData string) ([]types.Call, error) {
+	_, calls, err := DecodeCallData(callData)
+	return calls, err
+}
+
+// VerifyBuildResponse checks that the builder response is sent from the requested account and that its
+// callData performs exactly the requested calls.
+// Only single and batch modes with the default exec type and no mode selector or payload are accepted, since
+// other modes cannot be expressed in a BuildUserOpRequest: a try exec type would let failing calls pass silently.
+// Returns an error wrapping ErrCallsMismatch when the calls differ.
+func VerifyBuildResponse(req *types.BuildUserOpRequest, resp *types.BuildUserOpResponse) error {
+	if req == nil || resp == nil {
+		return fmt.Errorf("request and response are required")
+	}
+	if !sameAddress(req.Account, resp.Sender) {
+		return fmt.Errorf("%w: sender %s does not match account %s", ErrCallsMismatch, resp.Sender, req.Account)
+	}
+
+	mode, calls, err := DecodeCallData(resp.CallData)
+	if err != nil {
+		return err
+	}
+	if mode.CallType == CallTypeDelegateCall {
+		return fmt.Errorf("%w: unexpected delegatecall to %s", ErrCallsMismatch, calls[0].To)
+	}
+	// DecodeCallData succeeded, so callData holds a 32-byte mode after the selector, including the unused bytes
+	// that ExecMode drops.
+	raw, _ := hexutil.Decode(resp.CallData)
+	if want := (ExecMode{CallType: mode.CallType}).Bytes(); !bytes.Equal(raw[4:36], want[:]) {
+		return fmt.Errorf("%w: unexpected execution mode 0x%x", ErrCallsMismatch, raw[4:36])
+	}
+	if len(calls) != len(req.Calls) {
+		return fmt.Errorf("%w: expected %d calls, got %d", ErrCallsMismatch, len(req.Calls), len(calls))
+	}
+
+	for i := range req.Calls {
+		if err := compareCall(req.Calls[i], calls[i]); err != nil {
+			return fmt.Errorf("%w: call %d: %v", ErrCallsMismatch, i, err)
+		}
+	}
+
+	return nil
+}
+
+func compareCall(want, got types.Call) error {
+	if !sameAddress(want.To, got.To) {
+		return fmt.Errorf("target %s does not match %s", got.To, want.To)
+	}
+
+	wantValue, err := userop.ParseUint256(want.Value)
+	if err != nil {
+		return fmt.Errorf("invalid requested value: %w", err)
+	}
+	gotValue, err := userop.ParseUint256(got.Value)
+	if err != nil {
+		return fmt.Errorf("invalid decoded value: %w", err)
+	}
+	if wantValue.Cmp(gotValue) != 0 {
+		return fmt.Errorf("value %s does not match %s", gotValue, wantValue)
+	}
+
+	wantData, err := decodeData(want.Data)
+	if err != nil {
+		return fmt.Errorf("invalid requested data: %w", err)
+	}
+	gotData, err := decodeData(got.Data)
+	if err != nil {
+		return fmt.Errorf("invalid decoded data: %w", err)
+	}
+	if !bytes.Equal(wantData, gotData) {
+		return fmt.Errorf("data %s does not match %s", got.Data, want.Data)
+	}
+
+	return nil
+}
+
+func parseCall(call types.Call) (execution, error) {
+	if !common.IsHexAddress(call.To) {
+		return execution{}, fmt.Errorf("invalid target address: %q", call.To)
+	}
+	value, err := userop.ParseUint256(call.Value)
+	if err != nil {
+		return execution{}, fmt.Errorf("invalid value: %w", err)
+	}
+	data, err := decodeData(call.Data)
+	if err != nil {
+		return execution{}, fmt.Errorf("invalid data: %w", err)
+	}
+	return execution{Target: common.HexToAddress(call.To), Value: value, CallData: data}, nil
+}
+
+func newCall(to common.Address, value *big.Int, data []byte) types.Call {
+	return types.Call{
+		To:    to.Hex(),
+		Value: value.String(),
+		Data:  hexutil.Encode(data),
+	}
+}
+
+func sameAddress(a, b string) bool {
+	return common.IsHexAddress(a) && common.IsHexAddress(b) && common.HexToAddress(a) == common.HexToAddress(b)
+}
+
+func decodeData(data string) ([]byte, error) {
+	if data == "" {
+		return []byte{}, nil
+	}
+	return hexutil.Decode(data)
+}
@@ -0,0 +1,237 @@
+package kernel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+const (
+	testTarget   = "0x2222222222222222222222222222222222222222"
+	testTransfer = "0xa9059cbb00000000000000000000000033333333333333333333333333333333333333330000000000000000000000000000000000000000000000000000000000000064"
+
+	// Kernel v3 execute(bytes32,bytes) encodings built independently from the ERC-7579 execution layouts.
+	testSingleCallData   = "0xe9ae5c5300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000007822222222222222222222222222222222222222220000000000000000000000000000000000000000000000000de0b6b3a7640000a9059cbb000000000000000000000000333333333333333333333333333333333333333300000000000000000000000000000000000000000000000000000000000000640000000000000000"
+	testBatchCallData    = "0xe9ae5c530100000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000001e000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000001200000000000000000000000002222222222222222222222222222222222222222000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000044a9059cbb00000000000000000000000033333333333333333333333333333333333333330000000000000000000000000000000000000000000000000000000000000064000000000000000000000000000000000000000000000000000000000000000000000000000000004444444444444444444444444444444444444444000000000000000000000000000000000000000000000000000000000000000500000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000000"
+	testDelegateCallData = "0xe9ae5c53ff00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000185555555555555555555555555555555555555555123456780000000000000000"
+	testTrySingleData    = "0xe9ae5c5300010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000003422222222222222222222222222222222222222220000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"
+)
+
+func TestEncodeCallData(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []types.Call
+		want  string
+	}{
+		{
+			name:  "single",
+			calls: []types.Call{{To: testTarget, Value: "1000000000000000000", Data: testTransfer}},
+			want:  testSingleCallData,
+		},
+		{
+			name: "batch",
+			calls: []types.Call{
+				{To: testTarget, Value: "0", Data: testTransfer},
+				{To: "0x4444444444444444444444444444444444444444", Value: "0x5"},
+			},
+			want: testBatchCallData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeCallData(constants.KernelVersion033, tt.calls)
+			if err != nil {
+				t.Fatalf("EncodeCallData() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeCallData() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  ExecMode
+		calls []types.Call
+		want  string
+	}{
+		{
+			name:  "delegatecall",
+			mode:  ExecMode{CallType: CallTypeDelegateCall},
+			calls: []types.Call{{To: "0x5555555555555555555555555555555555555555", Data: "0x12345678"}},
+			want:  testDelegateCallData,
+		},
+		{
+			name:  "try single",
+			mode:  ExecMode{CallType: CallTypeSingle, ExecType: ExecTypeTry},
+			calls: []types.Call{{To: testTarget}},
+			want:  testTrySingleData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeExecute(constants.KernelVersion033, tt.mode, tt.calls)
+			if err != nil {
+				t.Fatalf("EncodeExecute() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeExecute() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeExecuteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  ExecMode
+		calls []types.Call
+	}{
+		{"no calls", ExecMode{CallType: CallTypeSingle}, nil},
+		{"single with two calls", ExecMode{CallType: CallTypeSingle}, []types.Call{{To: testTarget}, {To: testTarget}}},
+		{"delegatecall with value", ExecMode{CallType: CallTypeDelegateCall}, []types.Call{{To: testTarget, Value: "1"}}},
+		{"invalid target", ExecMode{CallType: CallTypeSingle}, []types.Call{{To: "0x1234"}}},
+		{"unsupported call type", ExecMode{CallType: 0x02}, []types.Call{{To: testTarget}}},
+		{"unsupported exec type", ExecMode{ExecType: 0x02}, []types.Call{{To: testTarget}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := EncodeExecute(constants.KernelVersion033, tt.mode, tt.calls); err == nil {
+				t.Error("EncodeExecute() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestDecodeCallData(t *testing.T) {
+	tests := []struct {
+		name     string
+		callData string
+		mode     ExecMode
+		calls    []types.Call
+	}{
+		{
+			name:     "single",
+			callData: testSingleCallData,
+			mode:     ExecMode{CallType: CallTypeSingle},
+			calls:    []types.Call{{To: testTarget, Value: "1000000000000000000", Data: testTransfer}},
+		},
+		{
+			name:     "batch",
+			callData: testBatchCallData,
+			mode:     ExecMode{CallType: CallTypeBatch},
+			calls: []types.Call{
+				{To: testTarget, Value: "0", Data: testTransfer},
+				{To: "0x4444444444444444444444444444444444444444", Value: "5", Data: "0x"},
+			},
+		},
+		{
+			name:     "delegatecall",
+			callData: testDelegateCallData,
+			mode:     ExecMode{CallType: CallTypeDelegateCall},
+			calls:    []types.Call{{To: "0x5555555555555555555555555555555555555555", Value: "0", Data: "0x12345678"}},
+		},
+		{
+			name:     "try single",
+			callData: testTrySingleData,
+			mode:     ExecMode{CallType: CallTypeSingle, ExecType: ExecTypeTry},
+			calls:    []types.Call{{To: testTarget, Value: "0", Data: "0x"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, calls, err := DecodeCallData(tt.callData)
+			if err != nil {
+				t.Fatalf("DecodeCallData() error = %v", err)
+			}
+			if mode != tt.mode {
+				t.Errorf("DecodeCallData() mode = %+v, want %+v", mode, tt.mode)
+			}
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("DecodeCallData() calls = %+v, want %+v", calls, tt.calls)
+			}
+
+			// Re-encoding the decoded calls with the decoded mode yields the original callData.
+			encoded, err := EncodeExecute(constants.KernelVersion033, mode, calls)
+			if err != nil {
+				t.Fatalf("EncodeExecute() error = %v", err)
+			}
+			if encoded != tt.callData {
+				t.Errorf("round trip = %s, want %s", encoded, tt.callData)
+			}
+		})
+	}
+}
+
+func TestDecodeCallDataErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		callData string
+	}{
+		{"not hex", "0xzz"},
+		{"wrong selector", "0xa9059cbb" + testSingleCallData[10:]},
+		{"truncated", testSingleCallData[:74]},
+		{"single too short", "0xe9ae5c53" + strings.Repeat("0", 64) + "0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000014" + testTarget[2:] + strings.Repeat("0", 24)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := DecodeCallData(tt.callData); err == nil {
+				t.Error("DecodeCallData() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyBuildResponse(t *testing.T) {
+	account := "0x6666666666666666666666666666666666666666"
+	req := &types.BuildUserOpRequest{
+		Account: account,
+		Calls:   []types.Call{{To: testTarget, Value: "1000000000000000000", Data: testTransfer}},
+	}
+
+	tests := []struct {
+		name    string
+		resp    *types.BuildUserOpResponse
+		wantErr bool
+	}{
+		{"matching", &types.BuildUserOpResponse{Sender: account, CallData: testSingleCallData}, false},
+		{"other sender", &types.BuildUserOpResponse{Sender: testTarget, CallData: testSingleCallData}, true},
+		{"other calls", &types.BuildUserOpResponse{Sender: account, CallData: testBatchCallData}, true},
+		{"delegatecall", &types.BuildUserOpResponse{Sender: account, CallData: testDelegateCallData}, true},
+		{"try exec type", &types.BuildUserOpResponse{Sender: account, CallData: withModeByte(testSingleCallData, 1, 0x01)}, true},
+		{"unused mode bytes", &types.BuildUserOpResponse{Sender: account, CallData: withModeByte(testSingleCallData, 2, 0x01)}, true},
+		{"mode selector", &types.BuildUserOpResponse{Sender: account, CallData: withModeByte(testSingleCallData, 6, 0x01)}, true},
+		{"mode payload", &types.BuildUserOpResponse{Sender: account, CallData: withModeByte(testSingleCallData, 31, 0x01)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyBuildResponse(req, tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyBuildResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrCallsMismatch) {
+				t.Errorf("VerifyBuildResponse() error = %v, want %v", err, ErrCallsMismatch)
+			}
+		})
+	}
+}
+
+// withModeByte returns callData with byte i of its execution mode set to b.
+func withModeByte(callData string, i int, b byte) string {
+	offset := len("0x") + 8 + 2*i
+	return callData[:offset] + fmt.Sprintf("%02x", b) + callData[offset+2:]
+}
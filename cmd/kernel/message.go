@@ -0,0 +1,112 @@
+package kernel
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+)
+
+var (
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	kernelWrapTypeHash   = crypto.Keccak256Hash([]byte("Kernel(bytes32 hash)"))
+	uint256Type, _       = abi.NewType("uint256", "", nil)
+	domainArgs           = abi.Arguments{{Type: bytes32Type}, {Type: bytes32Type}, {Type: bytes32Type}, {Type: uint256Type}, {Type: addressType}}
+	wrapArgs             = abi.Arguments{{Type: bytes32Type}, {Type: bytes32Type}}
+)
+
+// DomainSeparator returns the EIP-712 domain separator of a Kernel account:
+// name "Kernel", version set to the kernel version, chainId and the account as verifying contract.
+func DomainSeparator(version constants.KernelVersion, account common.Address, chainID uint64) (common.Hash, error) {
+	if _, err := constants.GetKernelAddresses(version); err != nil {
+		return common.Hash{}, err
+	}
+
+	encoded, err := domainArgs.Pack(
+		eip712DomainTypeHash,
+		crypto.Keccak256Hash([]byte("Kernel")),
+		crypto.Keccak256Hash([]byte(version)),
+		new(big.Int).SetUint64(chainID),
+		account,
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode domain: %w", err)
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// WrapMessageHash returns the replay-safe digest Kernel v3 validates in isValidSignature:
+// keccak256(0x1901 || domainSeparator || keccak256(abi.encode(keccak256("Kernel(bytes32 hash)"), hash))).
+func WrapMessageHash(version constants.KernelVersion, account common.Address, chainID uint64, hash common.Hash) (common.Hash, error) {
+	domainSeparator, err := DomainSeparator(version, account, chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	encoded, err := wrapArgs.Pack(kernelWrapTypeHash, hash)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode wrapped hash: %w", err)
+	}
+	structHash := crypto.Keccak256(encoded)
+
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), structHash), nil
+}
+
+// EncodeValidatorSignature prefixes a validator signature with its Kernel validator identifier.
+// The root validator is selected with the single byte 0x00; other validators use the full 21-byte identifier.
+func EncodeValidatorSignature(validatorID [21]byte, signature []byte) []byte {
+	if ValidatorType(validatorID[0]) == ValidatorTypeRoot {
+		return append([]byte{byte(ValidatorTypeRoot)}, signature...)
+	}
+	return append(validatorID[:], signature...)
+}
+
+// SignHash signs hash through the Kernel account, with s owning the ECDSA-compatible validator
+// identified by validatorID. The hash is wrapped with WrapMessageHash and the result is encoded for isValidSignature.
+func SignHash(ctx context.Context, s signer.Signer, version constants.KernelVersion, account string, chainID uint64, validatorID [21]byte, hash common.Hash) (string, error) {
+	if !common.IsHexAddress(account) {
+		return "", fmt.Errorf("invalid account address: %q", account)
+	}
+
+	wrapped, err := WrapMessageHash(version, common.HexToAddress(account), chainID, hash)
+	if err != nil {
+		return "", err
+	}
+
+	// The ECDSA validator accepts both raw and personal_sign signatures of the wrapped hash.
+	signature, err := s.SignMessage(ctx, wrapped.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign wrapped hash: %w", err)
+	}
+
+	return hexutil.Encode(EncodeValidatorSignature(validatorID, signature)), nil
+}
+
+// SignMessage signs a personal message (EIP-191) through the Kernel account using the default
+// ECDSA validator, in a form that Kernel v3's isValidSignature accepts.
+func SignMessage(ctx context.Context, s signer.Signer, version constants.KernelVersion, account string, chainID uint64, message []byte) (string, error) {
+	return SignHash(ctx, s, version, account, chainID, defaultValidatorID(), common.BytesToHash(accounts.TextHash(message)))
+}
+
+// SignTypedData signs EIP-712 typed data through the Kernel account using the default
+// ECDSA validator, in a form that Kernel v3's isValidSignature accepts.
+func SignTypedData(ctx context.Context, s signer.Signer, version constants.KernelVersion, account string, chainID uint64, typedData apitypes.TypedData) (string, error) {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	return SignHash(ctx, s, version, account, chainID, defaultValidatorID(), common.BytesToHash(hash))
+}
+
+// defaultValidatorID identifies the ECDSA validator installed by GetECDSAInitData.
+func defaultValidatorID() [21]byte {
+	return ValidatorID(ValidatorTypeValidator, common.HexToAddress(constants.ECDSAValidatorAddress))
+}
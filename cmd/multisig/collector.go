@@ -0,0 +1,243 @@
+package multisig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+var (
+	// ErrThresholdNotMet is returned when assembling a signature before the collected weight reaches the threshold.
+	ErrThresholdNotMet = errors.New("multisig threshold not met")
+	// ErrUnknownSigner is returned when a partial signature comes from an address outside the signer set.
+	ErrUnknownSigner = errors.New("signer is not part of the multisig")
+	// ErrDuplicateSignature is returned when a signer submits a second partial signature.
+	ErrDuplicateSignature = errors.New("signer already signed")
+)
+
+// PartialSignature is one signer's contribution to a multisig user operation, in a JSON form
+// that can be passed between processes. Each signer signs both the approval digest and the userOpHash,
+// so the collector can use either depending on the signer's position in the final signature.
+type PartialSignature struct {
+	Signer          string `json:"signer"`
+	Approval        string `json:"approval"`        // Signature of the ApprovalHash
+	UserOpSignature string `json:"userOpSignature"` // personal_sign signature of the userOpHash
+}
+
+// Collector gathers partial signatures for one user operation, possibly from several goroutines,
+// and assembles the validator signature once the threshold is met.
+type Collector struct {
+	config       *Config
+	approvalHash common.Hash
+	userOpHash   common.Hash
+
+	mu       sync.Mutex
+	partials map[common.Address]*PartialSignature
+	weight   uint64
+	done     chan struct{}
+}
+
+// NewCollector recomputes the userOpHash of a builder response locally and prepares collecting signatures for it.
+// Refuses user operations whose computed hash does not match the UserOpHash returned by the builder.
+func NewCollector(config *Config, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64) (*Collector, error) {
+	approvalHash, userOpHash, err := hashes(op, entryPointVersion, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Collector{
+		config:       config,
+		approvalHash: approvalHash,
+		userOpHash:   userOpHash,
+		partials:     make(map[common.Address]*PartialSignature),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+// SignPartial signs a builder response as one signer of a multisig account.
+// Refuses to sign when the computed hash does not match the UserOpHash returned by the builder.
+func SignPartial(ctx context.Context, s signer.Signer, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64) (*PartialSignature, error) {
+	approvalHash, userOpHash, err := hashes(op, entryPointVersion, chainID)
+	if err != nil {
+		return nil, err
+	}
+	return signPartial(ctx, s, approvalHash, userOpHash)
+}
+
+// ApprovalHash returns the approval digest of the user operation.
+func (c *Collector) ApprovalHash() common.Hash {
+	return c.approvalHash
+}
+
+// UserOpHash returns the locally computed userOpHash.
+func (c *Collector) UserOpHash() common.Hash {
+	return c.userOpHash
+}
+
+// Sign signs the user operation with s and adds the partial signature.
+func (c *Collector) Sign(ctx context.Context, s signer.Signer) error {
+	partial, err := signPartial(ctx, s, c.approvalHash, c.userOpHash)
+	if err != nil {
+		return err
+	}
+	return c.Add(partial)
+}
+
+// Add verifies and records a partial signature.
+// Returns ErrUnknownSigner, ErrDuplicateSignature or a verification error when it is rejected.
+func (c *Collector) Add(partial *PartialSignature) error {
+	if !common.IsHexAddress(partial.Signer) {
+		return fmt.Errorf("invalid signer address: %q", partial.Signer)
+	}
+	address := common.HexToAddress(partial.Signer)
+	weight := c.config.Weight(address)
+	if weight == 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownSigner, address.Hex())
+	}
+
+	approval, err := hexutil.Decode(partial.Approval)
+	if err != nil || !recoversTo(c.approvalHash.Bytes(), approval, address) {
+		return fmt.Errorf("invalid approval signature from %s", address.Hex())
+	}
+	userOpSignature, err := hexutil.Decode(partial.UserOpSignature)
+	if err != nil || !recoversTo(accounts.TextHash(c.userOpHash.Bytes()), userOpSignature, address) {
+		return fmt.Errorf("invalid user operation signature from %s", address.Hex())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.partials[address]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateSignature, address.Hex())
+	}
+	c.partials[address] = partial
+	wasReady := c.weight >= uint64(c.config.Threshold)
+	c.weight += uint64(weight)
+	if !wasReady && c.weight >= uint64(c.config.Threshold) {
+		close(c.done)
+	}
+	return nil
+}
+
+// Weight returns the total weight of the collected signatures.
+func (c *Collector) Weight() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.weight
+}
+
+// Signers returns the addresses that signed so far, in signer set order.
+func (c *Collector) Signers() []common.Address {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	signers := make([]common.Address, 0, len(c.partials))
+	for _, s := range c.config.Signers {
+		if _, ok := c.partials[s.Address]; ok {
+			signers = append(signers, s.Address)
+		}
+	}
+	return signers
+}
+
+// Ready reports whether the collected weight reaches the threshold.
+func (c *Collector) Ready() bool {
+	return c.Weight() >= uint64(c.config.Threshold)
+}
+
+// Done returns a channel closed once the collected weight reaches the threshold.
+func (c *Collector) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait blocks until the threshold is met or ctx is done.
+func (c *Collector) Wait(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Signature assembles the validator signature from the collected partial signatures: approvals of the
+// first signers in signer set order, followed by the userOpHash signature of the signer reaching the threshold.
+// Returns signature as hex string (0x-prefixed), or an error wrapping ErrThresholdNotMet.
+func (c *Collector) Signature() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		signature []byte
+		weight    uint64
+	)
+	for _, s := range c.config.Signers {
+		partial, ok := c.partials[s.Address]
+		if !ok {
+			continue
+		}
+		weight += uint64(s.Weight)
+		if weight >= uint64(c.config.Threshold) {
+			return hexutil.Encode(append(signature, common.FromHex(partial.UserOpSignature)...)), nil
+		}
+		signature = append(signature, common.FromHex(partial.Approval)...)
+	}
+	return "", fmt.Errorf("%w: collected weight %d of %d", ErrThresholdNotMet, weight, c.config.Threshold)
+}
+
+// hashes recomputes the userOpHash of op and derives its approval digest.
+func hashes(op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64) (common.Hash, common.Hash, error) {
+	userOpHash, err := userop.VerifyUserOpHash(op, entryPointVersion, chainID)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("refusing to sign user operation: %w", err)
+	}
+	packed, err := userop.Pack(op, entryPointVersion)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	approvalHash, err := userOpApprovalHash(packed, chainID)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	return approvalHash, userOpHash, nil
+}
+
+// signPartial signs the approval digest and the userOpHash with s.
+func signPartial(ctx context.Context, s signer.Signer, approvalHash, userOpHash common.Hash) (*PartialSignature, error) {
+	approval, err := s.SignHash(ctx, approvalHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign approval: %w", err)
+	}
+	userOpSignature, err := s.SignMessage(ctx, userOpHash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign user operation hash: %w", err)
+	}
+
+	return &PartialSignature{
+		Signer:          s.Address().Hex(),
+		Approval:        hexutil.Encode(approval),
+		UserOpSignature: hexutil.Encode(userOpSignature),
+	}, nil
+}
+
+// recoversTo reports whether a 65-byte signature of digest recovers to address.
+func recoversTo(digest []byte, signature []byte, address common.Address) bool {
+	if len(signature) != 65 {
+		return false
+	}
+	sig := append([]byte{}, signature...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pubKey, err := crypto.SigToPub(digest, sig)
+	return err == nil && crypto.PubkeyToAddress(*pubKey) == address
+}
@@ -0,0 +1,173 @@
+package multisig
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+const testChainID = 8453
+
+// newTestCollector returns a collector for a 2 of 3 signer set of fresh keys, and the keys in signer set order.
+func newTestCollector(t *testing.T) (*Collector, []*signer.PrivateKeySigner) {
+	t.Helper()
+	bySigner := make(map[common.Address]*signer.PrivateKeySigner)
+	var signers []WeightedSigner
+	for range 3 {
+		s := newKey(t)
+		bySigner[s.Address()] = s
+		signers = append(signers, WeightedSigner{Address: s.Address(), Weight: 1})
+	}
+	config, err := NewConfig(signers, 2, 0)
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	keys := make([]*signer.PrivateKeySigner, 0, len(config.Signers))
+	for _, s := range config.Signers {
+		keys = append(keys, bySigner[s.Address])
+	}
+
+	op := &types.BuildUserOpResponse{
+		Sender:               "0x6666666666666666666666666666666666666666",
+		Nonce:                "0x5",
+		CallData:             "0x1234",
+		CallGasLimit:         "100000",
+		VerificationGasLimit: "200000",
+		PreVerificationGas:   "50000",
+		MaxFeePerGas:         "3000000000",
+		MaxPriorityFeePerGas: "1000000000",
+	}
+	hash, err := userop.GetUserOpHash(op, constants.EntryPointVersion07, testChainID)
+	if err != nil {
+		t.Fatalf("GetUserOpHash() error = %v", err)
+	}
+	op.UserOpHash = hash.Hex()
+
+	c, err := NewCollector(config, op, constants.EntryPointVersion07, testChainID)
+	if err != nil {
+		t.Fatalf("NewCollector() error = %v", err)
+	}
+	return c, keys
+}
+
+// newKey returns a signer of a fresh key.
+func newKey(t *testing.T) *signer.PrivateKeySigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return signer.NewPrivateKeySigner(key)
+}
+
+// partial signs the collector's user operation with s.
+func partial(t *testing.T, c *Collector, s signer.Signer) *PartialSignature {
+	t.Helper()
+	p, err := signPartial(context.Background(), s, c.ApprovalHash(), c.UserOpHash())
+	if err != nil {
+		t.Fatalf("signPartial() error = %v", err)
+	}
+	return p
+}
+
+func TestNewCollectorHashMismatch(t *testing.T) {
+	op := &types.BuildUserOpResponse{
+		Sender:     "0x6666666666666666666666666666666666666666",
+		Nonce:      "0x5",
+		CallData:   "0x1234",
+		UserOpHash: "0x1111111111111111111111111111111111111111111111111111111111111111",
+	}
+	config, err := NewConfig([]WeightedSigner{{Address: testSigner1, Weight: 1}}, 1, 0)
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if _, err := NewCollector(config, op, constants.EntryPointVersion07, testChainID); err == nil {
+		t.Error("NewCollector() accepted a user operation whose hash does not match")
+	}
+}
+
+func TestCollectorAdd(t *testing.T) {
+	c, keys := newTestCollector(t)
+	outsider := newKey(t)
+
+	if err := c.Add(partial(t, c, outsider)); !errors.Is(err, ErrUnknownSigner) {
+		t.Errorf("Add() of unknown signer error = %v, want %v", err, ErrUnknownSigner)
+	}
+
+	// A partial signature claiming another signer's address is rejected.
+	forged := partial(t, c, outsider)
+	forged.Signer = keys[0].Address().Hex()
+	if err := c.Add(forged); err == nil {
+		t.Error("Add() accepted a partial signature of another key")
+	}
+
+	if err := c.Add(partial(t, c, keys[0])); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add(partial(t, c, keys[0])); !errors.Is(err, ErrDuplicateSignature) {
+		t.Errorf("Add() of duplicate signer error = %v, want %v", err, ErrDuplicateSignature)
+	}
+	if c.Weight() != 1 || c.Ready() {
+		t.Errorf("Weight() = %d, Ready() = %v, want 1, false", c.Weight(), c.Ready())
+	}
+}
+
+func TestCollectorSignature(t *testing.T) {
+	tests := []struct {
+		name     string
+		signed   []int // Indexes in signer set order of the keys signing, in signing order
+		approved []int // Signers whose approval is in the signature
+		last     int   // Signer whose userOpHash signature ends the signature, -1 when the threshold is not met
+	}{
+		{"not enough signers", []int{1}, nil, -1},
+		{"first signers", []int{0, 1}, []int{0}, 1},
+		{"signed in reverse order", []int{2, 0}, []int{0}, 2},
+		{"every signer", []int{2, 1, 0}, []int{0}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, keys := newTestCollector(t)
+			partials := make(map[int]*PartialSignature)
+			for _, i := range tt.signed {
+				partials[i] = partial(t, c, keys[i])
+				if err := c.Add(partials[i]); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+
+			got, err := c.Signature()
+			if tt.last < 0 {
+				if !errors.Is(err, ErrThresholdNotMet) {
+					t.Errorf("Signature() error = %v, want %v", err, ErrThresholdNotMet)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Signature() error = %v", err)
+			}
+			select {
+			case <-c.Done():
+			default:
+				t.Error("Done() is not closed once the threshold is met")
+			}
+
+			var want []byte
+			for _, i := range tt.approved {
+				want = append(want, common.FromHex(partials[i].Approval)...)
+			}
+			want = append(want, common.FromHex(partials[tt.last].UserOpSignature)...)
+			if got != hexutil.Encode(want) {
+				t.Errorf("Signature() = %s, want %s", got, hexutil.Encode(want))
+			}
+		})
+	}
+}
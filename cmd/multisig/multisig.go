@@ -0,0 +1,187 @@
+// Package multisig implements the Kernel v3 weighted ECDSA validator, where an account is owned by
+// several signers with weights and user operations need approvals reaching a threshold.
+package multisig
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// Domain of the EIP-712 approvals checked by the weighted ECDSA validator
+const (
+	domainName    = "WeightedECDSAValidator"
+	domainVersion = "0.0.3"
+)
+
+// maxUint24 bounds weights and thresholds, stored as uint24 by the validator.
+const maxUint24 = 1<<24 - 1
+
+var (
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	approveTypeHash      = crypto.Keccak256Hash([]byte("Approve(bytes32 callDataAndNonceHash)"))
+
+	addressType, _      = abi.NewType("address", "", nil)
+	addressArrayType, _ = abi.NewType("address[]", "", nil)
+	uint24Type, _       = abi.NewType("uint24", "", nil)
+	uint24ArrayType, _  = abi.NewType("uint24[]", "", nil)
+	uint48Type, _       = abi.NewType("uint48", "", nil)
+	uint256Type, _      = abi.NewType("uint256", "", nil)
+	bytes32Type, _      = abi.NewType("bytes32", "", nil)
+	bytesType, _        = abi.NewType("bytes", "", nil)
+	installDataArgs     = abi.Arguments{{Type: addressArrayType}, {Type: uint24ArrayType}, {Type: uint24Type}, {Type: uint48Type}}
+	domainArgs          = abi.Arguments{{Type: bytes32Type}, {Type: bytes32Type}, {Type: bytes32Type}, {Type: uint256Type}, {Type: addressType}}
+	approveArgs         = abi.Arguments{{Type: bytes32Type}, {Type: bytes32Type}}
+	callDataAndNonceArg = abi.Arguments{{Type: addressType}, {Type: bytesType}, {Type: uint256Type}}
+)
+
+// WeightedSigner is a signer of a multisig account and the weight of its approval.
+type WeightedSigner struct {
+	Address common.Address
+	Weight  uint32
+}
+
+// Config is the signer set of a multisig account. Signers are kept sorted by descending address,
+// the order in which the validator is installed and signatures are assembled.
+type Config struct {
+	Signers   []WeightedSigner
+	Threshold uint32
+	Delay     time.Duration // Delay before an approved user operation becomes valid
+}
+
+// NewConfig validates a signer set and returns it sorted.
+// Signers must be unique with non-zero weights, and the threshold must be reachable.
+func NewConfig(signers []WeightedSigner, threshold uint32, delay time.Duration) (*Config, error) {
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("multisig requires at least one signer")
+	}
+	if delay < 0 {
+		return nil, fmt.Errorf("delay must not be negative, got %s", delay)
+	}
+
+	sorted := append([]WeightedSigner{}, signers...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Address.Bytes(), sorted[j].Address.Bytes()) > 0
+	})
+
+	var total uint64
+	for i, s := range sorted {
+		if s.Address == (common.Address{}) {
+			return nil, fmt.Errorf("signer address must not be zero")
+		}
+		if i > 0 && s.Address == sorted[i-1].Address {
+			return nil, fmt.Errorf("duplicate signer %s", s.Address.Hex())
+		}
+		if s.Weight == 0 || s.Weight > maxUint24 {
+			return nil, fmt.Errorf("signer %s weight out of range: %d", s.Address.Hex(), s.Weight)
+		}
+		total += uint64(s.Weight)
+	}
+	if threshold == 0 || threshold > maxUint24 {
+		return nil, fmt.Errorf("threshold out of range: %d", threshold)
+	}
+	if uint64(threshold) > total {
+		return nil, fmt.Errorf("threshold %d exceeds total weight %d", threshold, total)
+	}
+
+	return &Config{Signers: sorted, Threshold: threshold, Delay: delay}, nil
+}
+
+// Weight returns the weight of signer, or zero when it is not part of the set.
+func (c *Config) Weight(signer common.Address) uint32 {
+	for _, s := range c.Signers {
+		if s.Address == signer {
+			return s.Weight
+		}
+	}
+	return 0
+}
+
+// InstallData returns the weighted ECDSA validator install data:
+// abi.encode(address[] signers, uint24[] weights, uint24 threshold, uint48 delay).
+func InstallData(config *Config) ([]byte, error) {
+	addresses := make([]common.Address, 0, len(config.Signers))
+	weights := make([]*big.Int, 0, len(config.Signers))
+	for _, s := range config.Signers {
+		addresses = append(addresses, s.Address)
+		weights = append(weights, big.NewInt(int64(s.Weight)))
+	}
+
+	encoded, err := installDataArgs.Pack(addresses, weights, big.NewInt(int64(config.Threshold)), big.NewInt(int64(config.Delay/time.Second)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode multisig install data: %w", err)
+	}
+	return encoded, nil
+}
+
+// ValidatorID returns the Kernel validator identifier of the weighted ECDSA validator.
+func ValidatorID() [21]byte {
+	return kernel.ValidatorID(kernel.ValidatorTypeValidator, common.HexToAddress(constants.WeightedECDSAValidatorAddress))
+}
+
+// GetInitData returns the Kernel initialize callData for an account owned by the signer set.
+func GetInitData(version constants.KernelVersion, config *Config) ([]byte, error) {
+	installData, err := InstallData(config)
+	if err != nil {
+		return nil, err
+	}
+	return kernel.GetInitData(version, ValidatorID(), installData)
+}
+
+// ComputeAccountAddress derives the counterfactual address of a Kernel account owned by the signer set.
+func ComputeAccountAddress(version constants.KernelVersion, config *Config, index *big.Int) (common.Address, error) {
+	initData, err := GetInitData(version, config)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return kernel.ComputeAccountAddress(version, initData, index)
+}
+
+// GetFactoryData returns the Factory and FactoryData pair deploying a Kernel account owned by the signer set.
+func GetFactoryData(version constants.KernelVersion, config *Config, index *big.Int) (string, string, error) {
+	initData, err := GetInitData(version, config)
+	if err != nil {
+		return "", "", err
+	}
+	return kernel.GetFactoryData(version, initData, index)
+}
+
+// ApprovalHash returns the EIP-712 digest each signer signs to approve a user operation:
+// an Approve struct over keccak256(abi.encode(sender, callData, nonce)), in the validator's domain.
+func ApprovalHash(chainID uint64, sender common.Address, callData []byte, nonce *big.Int) (common.Hash, error) {
+	encoded, err := callDataAndNonceArg.Pack(sender, callData, nonce)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode approval: %w", err)
+	}
+	approved, err := approveArgs.Pack(approveTypeHash, crypto.Keccak256Hash(encoded))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode approval: %w", err)
+	}
+
+	domain, err := domainArgs.Pack(
+		eip712DomainTypeHash,
+		crypto.Keccak256Hash([]byte(domainName)),
+		crypto.Keccak256Hash([]byte(domainVersion)),
+		new(big.Int).SetUint64(chainID),
+		common.HexToAddress(constants.WeightedECDSAValidatorAddress),
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode domain: %w", err)
+	}
+
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, crypto.Keccak256(domain), crypto.Keccak256(approved)), nil
+}
+
+// userOpApprovalHash returns the ApprovalHash of a packed user operation.
+func userOpApprovalHash(op *userop.PackedUserOperation, chainID uint64) (common.Hash, error) {
+	return ApprovalHash(chainID, op.Sender, op.CallData, op.Nonce)
+}
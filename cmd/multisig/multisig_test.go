@@ -0,0 +1,123 @@
+package multisig
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	testSigner1 = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	testSigner2 = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	testSigner3 = common.HexToAddress("0x3333333333333333333333333333333333333333")
+)
+
+func TestNewConfig(t *testing.T) {
+	config, err := NewConfig([]WeightedSigner{
+		{Address: testSigner1, Weight: 1},
+		{Address: testSigner3, Weight: 2},
+		{Address: testSigner2, Weight: 1},
+	}, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+
+	want := []common.Address{testSigner3, testSigner2, testSigner1}
+	for i, s := range config.Signers {
+		if s.Address != want[i] {
+			t.Errorf("NewConfig() signer %d = %s, want %s", i, s.Address.Hex(), want[i].Hex())
+		}
+	}
+	if got := config.Weight(testSigner3); got != 2 {
+		t.Errorf("Weight() = %d, want 2", got)
+	}
+	if got := config.Weight(common.HexToAddress("0x4444444444444444444444444444444444444444")); got != 0 {
+		t.Errorf("Weight() of unknown signer = %d, want 0", got)
+	}
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		signers   []WeightedSigner
+		threshold uint32
+		delay     time.Duration
+		wantErr   string
+	}{
+		{"no signers", nil, 1, 0, "at least one signer"},
+		{"duplicate signer", []WeightedSigner{{testSigner1, 1}, {testSigner2, 1}, {testSigner1, 1}}, 1, 0, "duplicate signer"},
+		{"zero address", []WeightedSigner{{common.Address{}, 1}}, 1, 0, "must not be zero"},
+		{"zero weight", []WeightedSigner{{testSigner1, 1}, {testSigner2, 0}}, 1, 0, "weight out of range"},
+		{"weight overflow", []WeightedSigner{{testSigner1, maxUint24 + 1}}, 1, 0, "weight out of range"},
+		{"zero threshold", []WeightedSigner{{testSigner1, 1}}, 0, 0, "threshold out of range"},
+		{"unreachable threshold", []WeightedSigner{{testSigner1, 1}, {testSigner2, 2}}, 4, 0, "exceeds total weight"},
+		{"negative delay", []WeightedSigner{{testSigner1, 1}}, 1, -time.Second, "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(tt.signers, tt.threshold, tt.delay)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInstallData(t *testing.T) {
+	config, err := NewConfig([]WeightedSigner{
+		{Address: testSigner1, Weight: 1},
+		{Address: testSigner3, Weight: 2},
+		{Address: testSigner2, Weight: 1},
+	}, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+
+	got, err := InstallData(config)
+	if err != nil {
+		t.Fatalf("InstallData() error = %v", err)
+	}
+	// abi.encode([0x33.., 0x22.., 0x11..], [2, 1, 1], 3, 60), built independently from the ABI layout.
+	want := "0x" +
+		"0000000000000000000000000000000000000000000000000000000000000080" +
+		"0000000000000000000000000000000000000000000000000000000000000100" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"000000000000000000000000000000000000000000000000000000000000003c" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000003333333333333333333333333333333333333333" +
+		"0000000000000000000000002222222222222222222222222222222222222222" +
+		"0000000000000000000000001111111111111111111111111111111111111111" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000001"
+	if hexutil.Encode(got) != want {
+		t.Errorf("InstallData() = %x, want %s", got, want)
+	}
+}
+
+func TestApprovalHash(t *testing.T) {
+	got, err := ApprovalHash(8453, common.HexToAddress("0x6666666666666666666666666666666666666666"), []byte{0x12, 0x34}, big.NewInt(5))
+	if err != nil {
+		t.Fatalf("ApprovalHash() error = %v", err)
+	}
+	// EIP-712 digest of Approve(keccak256(abi.encode(sender, callData, nonce))) in the WeightedECDSAValidator
+	// 0.0.3 domain, computed independently.
+	want := common.HexToHash("0x568ebd91d13f347b38a7ea095fc00c216e81c3502f58fc4c4addf655de20686a")
+	if got != want {
+		t.Errorf("ApprovalHash() = %s, want %s", got.Hex(), want.Hex())
+	}
+
+	other, err := ApprovalHash(1, common.HexToAddress("0x6666666666666666666666666666666666666666"), []byte{0x12, 0x34}, big.NewInt(5))
+	if err != nil {
+		t.Fatalf("ApprovalHash() error = %v", err)
+	}
+	if other == got {
+		t.Error("ApprovalHash() does not depend on the chain id")
+	}
+}
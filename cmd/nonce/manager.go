@@ -0,0 +1,282 @@
+package nonce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceManager hands out EntryPoint nonces per account and logical lane. Each lane has its own nonce key,
+// so user operations on different lanes never collide, while sequences within a lane are tracked locally.
+type NonceManager interface {
+	// Key returns the nonce key of lane for account, allocating one when the lane is new.
+	Key(ctx context.Context, account common.Address, lane string) (*big.Int, error)
+	// Next returns the next nonce of lane and advances its local sequence.
+	// The first call for a lane reads the sequence from the backend.
+	Next(ctx context.Context, account common.Address, lane string) (*big.Int, error)
+	// Resync resets the local sequence of lane from the backend, e.g. after a user operation was dropped.
+	Resync(ctx context.Context, account common.Address, lane string) (*big.Int, error)
+	// Release gives back a nonce returned by Next whose user operation was never sent, when it is still the
+	// last nonce allocated on lane. Reports whether the sequence was rewound; otherwise it is left alone,
+	// since later nonces of the lane may already be in use.
+	Release(ctx context.Context, account common.Address, lane string, nonce *big.Int) (bool, error)
+}
+
+// KeyFunc maps the index of a lane, allocated in order of first use per account, to its nonce key.
+type KeyFunc func(index uint16) *big.Int
+
+// Option configures a MemoryNonceManager or FileNonceManager.
+type Option func(*MemoryNonceManager)
+
+// WithKeyFunc sets how lane indexes map to nonce keys. Defaults to Kernel nonce keys of the root
+// validator in default mode, with lane index 0 being the plain key 0.
+func WithKeyFunc(keyFunc KeyFunc) Option {
+	return func(m *MemoryNonceManager) {
+		m.keyFunc = keyFunc
+	}
+}
+
+// laneState is the tracked state of a lane.
+type laneState struct {
+	Index    uint16 `json:"index"`
+	Key      string `json:"key"`
+	Sequence uint64 `json:"sequence"`
+	Synced   bool   `json:"-"`
+}
+
+// accountState is the tracked state of an account's lanes.
+type accountState struct {
+	Lanes map[string]*laneState `json:"lanes"`
+}
+
+// MemoryNonceManager is a NonceManager keeping lanes and sequences in memory.
+type MemoryNonceManager struct {
+	backend Backend
+	keyFunc KeyFunc
+	save    func(map[common.Address]*accountState) error
+
+	mu       sync.Mutex
+	accounts map[common.Address]*accountState
+}
+
+var _ NonceManager = (*MemoryNonceManager)(nil)
+var _ NonceManager = (*FileNonceManager)(nil)
+
+// NewMemoryNonceManager creates an in-memory NonceManager reading sequences through backend.
+func NewMemoryNonceManager(backend Backend, opts ...Option) *MemoryNonceManager {
+	m := &MemoryNonceManager{
+		backend:  backend,
+		keyFunc:  func(index uint16) *big.Int { return KernelKey(ValidationModeDefault, [21]byte{}, index) },
+		accounts: make(map[common.Address]*accountState),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Key returns the nonce key of lane for account, allocating one when the lane is new.
+func (m *MemoryNonceManager) Key(ctx context.Context, account common.Address, lane string) (*big.Int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.lane(account, lane)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := new(big.Int).SetString(state.Key, 0)
+	return key, nil
+}
+
+// Next returns the next nonce of lane and advances its local sequence.
+// The first call for a lane reads the sequence from the backend.
+func (m *MemoryNonceManager) Next(ctx context.Context, account common.Address, lane string) (*big.Int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.lane(account, lane)
+	if err != nil {
+		return nil, err
+	}
+	if !state.Synced {
+		if err := m.sync(ctx, account, state, true); err != nil {
+			return nil, err
+		}
+	}
+
+	key, _ := new(big.Int).SetString(state.Key, 0)
+	nonce, err := Encode(key, state.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	state.Sequence++
+	if err := m.persist(); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// Resync resets the local sequence of lane from the backend and returns the next nonce without allocating it.
+func (m *MemoryNonceManager) Resync(ctx context.Context, account common.Address, lane string) (*big.Int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.lane(account, lane)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.sync(ctx, account, state, false); err != nil {
+		return nil, err
+	}
+	key, _ := new(big.Int).SetString(state.Key, 0)
+	return Encode(key, state.Sequence)
+}
+
+// Release gives back nonce when it is the last nonce allocated on lane and reports whether it did.
+func (m *MemoryNonceManager) Release(ctx context.Context, account common.Address, lane string, nonce *big.Int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accountLanes, ok := m.accounts[account]
+	if !ok {
+		return false, nil
+	}
+	state, ok := accountLanes.Lanes[lane]
+	if !ok || state.Sequence == 0 {
+		return false, nil
+	}
+	key, _ := new(big.Int).SetString(state.Key, 0)
+	nonceKey, sequence := Decode(nonce)
+	if nonceKey.Cmp(key) != 0 || sequence != state.Sequence-1 {
+		return false, nil
+	}
+
+	state.Sequence--
+	if err := m.persist(); err != nil {
+		state.Sequence++
+		return false, err
+	}
+	return true, nil
+}
+
+// lane returns the state of lane, allocating the next lane index of account when it is new.
+func (m *MemoryNonceManager) lane(account common.Address, lane string) (*laneState, error) {
+	accountLanes, ok := m.accounts[account]
+	if !ok {
+		accountLanes = &accountState{Lanes: make(map[string]*laneState)}
+		m.accounts[account] = accountLanes
+	}
+	if state, ok := accountLanes.Lanes[lane]; ok {
+		return state, nil
+	}
+
+	if len(accountLanes.Lanes) > int(^uint16(0)) {
+		return nil, fmt.Errorf("account %s has no free nonce lanes", account.Hex())
+	}
+	index := uint16(len(accountLanes.Lanes))
+	key := m.keyFunc(index)
+	if key.Sign() < 0 || key.Cmp(maxKey) > 0 {
+		return nil, fmt.Errorf("nonce key out of range: %s", key)
+	}
+
+	state := &laneState{Index: index, Key: fmt.Sprintf("%#x", key)}
+	accountLanes.Lanes[lane] = state
+	if err := m.persist(); err != nil {
+		delete(accountLanes.Lanes, lane)
+		return nil, err
+	}
+	return state, nil
+}
+
+// sync reads the sequence of a lane from the backend. With keepAhead, a local sequence ahead of the
+// backend is kept, since user operations allocated before a restart may still be pending.
+func (m *MemoryNonceManager) sync(ctx context.Context, account common.Address, state *laneState, keepAhead bool) error {
+	key, _ := new(big.Int).SetString(state.Key, 0)
+	nonce, err := m.backend.GetNonce(ctx, account, key)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce of %s: %w", account.Hex(), err)
+	}
+	onChainKey, sequence := Decode(nonce)
+	if onChainKey.Cmp(key) != 0 {
+		return fmt.Errorf("backend returned nonce for key %#x, expected %#x", onChainKey, key)
+	}
+
+	if !keepAhead || sequence > state.Sequence {
+		state.Sequence = sequence
+	}
+	state.Synced = true
+	return m.persist()
+}
+
+// persist saves the state when the manager is backed by storage.
+func (m *MemoryNonceManager) persist() error {
+	if m.save == nil {
+		return nil
+	}
+	return m.save(m.accounts)
+}
+
+// FileNonceManager is a NonceManager persisting lanes and sequences to a JSON file, so allocated lanes
+// and sequences survive restarts. On first use after loading, a lane continues from the higher of its stored
+// and on-chain sequences.
+type FileNonceManager struct {
+	*MemoryNonceManager
+	path string
+}
+
+// NewFileNonceManager creates a NonceManager stored at path, loading its state when the file exists.
+func NewFileNonceManager(path string, backend Backend, opts ...Option) (*FileNonceManager, error) {
+	m := &FileNonceManager{
+		MemoryNonceManager: NewMemoryNonceManager(backend, opts...),
+		path:               path,
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read nonce state: %w", err)
+	default:
+		if err := json.Unmarshal(data, &m.accounts); err != nil {
+			return nil, fmt.Errorf("failed to decode nonce state: %w", err)
+		}
+		if m.accounts == nil {
+			m.accounts = make(map[common.Address]*accountState)
+		}
+	}
+
+	m.save = m.write
+	return m, nil
+}
+
+// write atomically replaces the state file.
+func (m *FileNonceManager) write(accounts map[common.Address]*accountState) error {
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode nonce state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write nonce state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write nonce state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write nonce state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("failed to write nonce state: %w", err)
+	}
+	return nil
+}
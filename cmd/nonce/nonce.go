@@ -0,0 +1,95 @@
+// Package nonce manages ERC-4337 two-dimensional nonces (192-bit key, 64-bit sequence), so one account
+// can have several user operations in flight on independent nonce lanes.
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ValidationMode is the Kernel v3 validation mode stored in the first byte of a nonce key.
+type ValidationMode byte
+
+// Supported Kernel v3 validation modes
+const (
+	ValidationModeDefault ValidationMode = 0x00
+	ValidationModeEnable  ValidationMode = 0x01
+)
+
+var (
+	addressType, _   = abi.NewType("address", "", nil)
+	uint192Type, _   = abi.NewType("uint192", "", nil)
+	uint256Type, _   = abi.NewType("uint256", "", nil)
+	getNonceArgs     = abi.Arguments{{Type: addressType}, {Type: uint192Type}}
+	getNonceResult   = abi.Arguments{{Type: uint256Type}}
+	getNonceSelector = crypto.Keccak256([]byte("getNonce(address,uint192)"))[:4]
+
+	maxKey      = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 192), big.NewInt(1))
+	maxSequence = new(big.Int).SetUint64(^uint64(0))
+)
+
+// KernelKey returns the Kernel v3 nonce key: mode || validator type || 20-byte validator identifier || 2-byte key.
+// validatorID is the 21-byte Kernel identifier (type || identifier), all zero for the root validator.
+func KernelKey(mode ValidationMode, validatorID [21]byte, key uint16) *big.Int {
+	encoded := make([]byte, 24)
+	encoded[0] = byte(mode)
+	copy(encoded[1:22], validatorID[:])
+	encoded[22] = byte(key >> 8)
+	encoded[23] = byte(key)
+	return new(big.Int).SetBytes(encoded)
+}
+
+// Encode combines a 192-bit nonce key and a 64-bit sequence into an EntryPoint nonce.
+func Encode(key *big.Int, sequence uint64) (*big.Int, error) {
+	if key.Sign() < 0 || key.Cmp(maxKey) > 0 {
+		return nil, fmt.Errorf("nonce key out of range: %s", key)
+	}
+	return new(big.Int).Or(new(big.Int).Lsh(key, 64), new(big.Int).SetUint64(sequence)), nil
+}
+
+// Decode splits an EntryPoint nonce into its 192-bit key and 64-bit sequence.
+func Decode(nonce *big.Int) (*big.Int, uint64) {
+	return new(big.Int).Rsh(nonce, 64), new(big.Int).And(nonce, maxSequence).Uint64()
+}
+
+// Backend reads the next EntryPoint nonce of an account for a nonce key.
+type Backend interface {
+	GetNonce(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error)
+}
+
+// EntryPointBackend reads nonces with EntryPoint.getNonce through an RPC client.
+type EntryPointBackend struct {
+	caller     bind.ContractCaller
+	entryPoint common.Address
+}
+
+// NewEntryPointBackend creates a Backend calling getNonce on entryPoint through caller, e.g. an *ethclient.Client.
+func NewEntryPointBackend(caller bind.ContractCaller, entryPoint common.Address) *EntryPointBackend {
+	return &EntryPointBackend{caller: caller, entryPoint: entryPoint}
+}
+
+// GetNonce returns the next nonce of account for key, sequence included.
+func (b *EntryPointBackend) GetNonce(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error) {
+	encoded, err := getNonceArgs.Pack(account, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode getNonce call: %w", err)
+	}
+
+	data := append(append([]byte{}, getNonceSelector...), encoded...)
+	result, err := b.caller.CallContract(ctx, ethereum.CallMsg{To: &b.entryPoint, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getNonce: %w", err)
+	}
+	values, err := getNonceResult.Unpack(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode getNonce result: %w", err)
+	}
+	return values[0].(*big.Int), nil
+}
@@ -0,0 +1,200 @@
+// Package paymaster implements an ERC-7677 paymaster web service client.
+package paymaster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/jsonrpc"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// Context is the paymaster-specific context object passed to ERC-7677 methods.
+// Its schema is defined by each paymaster service.
+type Context map[string]any
+
+// SponsorshipPolicyContext returns a context selecting a sponsorship policy by ID.
+func SponsorshipPolicyContext(policyID string) Context {
+	return Context{"sponsorshipPolicyId": policyID}
+}
+
+// ERC20TokenContext returns a context requesting gas payment in the given ERC-20 token.
+func ERC20TokenContext(token string) Context {
+	return Context{"token": token}
+}
+
+// Sponsor identifies the sponsor of a user operation, as shown to users by wallets.
+type Sponsor struct {
+	Name string `json:"name"`
+	Icon string `json:"icon,omitempty"`
+}
+
+// StubData represents the result of pm_getPaymasterStubData.
+// EntryPoint v0.7 and v0.8 use the unpacked fields, v0.6 uses PaymasterAndData.
+type StubData struct {
+	Sponsor                       *Sponsor `json:"sponsor,omitempty"`
+	Paymaster                     string   `json:"paymaster,omitempty"`
+	PaymasterData                 string   `json:"paymasterData,omitempty"`
+	PaymasterVerificationGasLimit string   `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       string   `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterAndData              string   `json:"paymasterAndData,omitempty"`
+	IsFinal                       bool     `json:"isFinal,omitempty"` // When true, pm_getPaymasterData must not be called
+}
+
+// Data represents the result of pm_getPaymasterData.
+type Data struct {
+	Paymaster        string `json:"paymaster,omitempty"`
+	PaymasterData    string `json:"paymasterData,omitempty"`
+	PaymasterAndData string `json:"paymasterAndData,omitempty"`
+}
+
+// PaymasterClient represents an ERC-7677 paymaster service client.
+type PaymasterClient struct {
+	rpc *jsonrpc.Client
+
+	httpClient *http.Client
+	headers    http.Header
+}
+
+// Option configures optional PaymasterClient behavior.
+type Option func(*PaymasterClient)
+
+// WithHTTPClient sets the HTTP client used for JSON-RPC requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *PaymasterClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header sent with every JSON-RPC request, e.g. for paymaster authentication.
+func WithHeader(name string, value string) Option {
+	return func(c *PaymasterClient) {
+		c.headers.Add(name, value)
+	}
+}
+
+// NewPaymasterClient creates a new paymaster client for the given service URL.
+func NewPaymasterClient(url string, opts ...Option) *PaymasterClient {
+	c := &PaymasterClient{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		headers: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.rpc = jsonrpc.NewClient(url, c.httpClient, c.headers)
+	return c
+}
+
+// GetPaymasterStubData calls pm_getPaymasterStubData for an unsigned user operation.
+// Any paymaster already set on op is left out of the request. The stub data is meant for
+// gas estimation and carries the paymaster gas limits.
+func (c *PaymasterClient) GetPaymasterStubData(ctx context.Context, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, pmContext Context) (*StubData, error) {
+	unsponsored := *op
+	clearPaymaster(&unsponsored)
+	params, err := requestParams(&unsponsored, entryPointVersion, chainID, pmContext)
+	if err != nil {
+		return nil, err
+	}
+
+	var stub StubData
+	if err := c.rpc.Call(ctx, &stub, "pm_getPaymasterStubData", params...); err != nil {
+		return nil, err
+	}
+	return &stub, nil
+}
+
+// GetPaymasterData calls pm_getPaymasterData for a user operation whose gas limits are final,
+// including the paymaster gas limits from the stub data.
+func (c *PaymasterClient) GetPaymasterData(ctx context.Context, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, pmContext Context) (*Data, error) {
+	params, err := requestParams(op, entryPointVersion, chainID, pmContext)
+	if err != nil {
+		return nil, err
+	}
+
+	var data Data
+	if err := c.rpc.Call(ctx, &data, "pm_getPaymasterData", params...); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// SponsorUserOp fills the paymaster fields of op in place. It fetches stub data for the paymaster
+// gas limits, then the final paymaster data unless the stub data is already final.
+// The gas limits of op must already be estimated. op.UserOpHash is updated to match the sponsored operation.
+func (c *PaymasterClient) SponsorUserOp(ctx context.Context, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, pmContext Context) error {
+	stub, err := c.GetPaymasterStubData(ctx, op, entryPointVersion, chainID, pmContext)
+	if err != nil {
+		return fmt.Errorf("failed to get paymaster stub data: %w", err)
+	}
+	stub.Apply(op)
+
+	if !stub.IsFinal {
+		data, err := c.GetPaymasterData(ctx, op, entryPointVersion, chainID, pmContext)
+		if err != nil {
+			return fmt.Errorf("failed to get paymaster data: %w", err)
+		}
+		data.Apply(op)
+	}
+
+	hash, err := userop.GetUserOpHash(op, entryPointVersion, chainID)
+	if err != nil {
+		return fmt.Errorf("failed to compute userOpHash: %w", err)
+	}
+	op.UserOpHash = hash.Hex()
+	return nil
+}
+
+// Apply sets the paymaster fields of op from the stub data, replacing any previous paymaster.
+// It does not update op.UserOpHash.
+func (s *StubData) Apply(op *types.BuildUserOpResponse) {
+	clearPaymaster(op)
+	op.Paymaster = s.Paymaster
+	op.PaymasterData = s.PaymasterData
+	op.PaymasterVerificationGasLimit = s.PaymasterVerificationGasLimit
+	op.PaymasterPostOpGasLimit = s.PaymasterPostOpGasLimit
+	op.PaymasterAndData = s.PaymasterAndData
+}
+
+// Apply sets the paymaster address and data of op, keeping the paymaster gas limits.
+// It does not update op.UserOpHash.
+func (d *Data) Apply(op *types.BuildUserOpResponse) {
+	if d.Paymaster != "" {
+		op.Paymaster = d.Paymaster
+	}
+	op.PaymasterData = d.PaymasterData
+	op.PaymasterAndData = d.PaymasterAndData
+}
+
+// requestParams builds the [userOp, entryPoint, chainId, context] params shared by both methods.
+func requestParams(op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, pmContext Context) ([]any, error) {
+	entryPoint, err := constants.GetEntryPointAddress(entryPointVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcOp, err := userop.ToRPC(op, op.Signature, entryPointVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if pmContext == nil {
+		pmContext = Context{}
+	}
+	return []any{rpcOp, entryPoint, hexutil.EncodeUint64(chainID), pmContext}, nil
+}
+
+func clearPaymaster(op *types.BuildUserOpResponse) {
+	op.Paymaster = ""
+	op.PaymasterData = ""
+	op.PaymasterVerificationGasLimit = ""
+	op.PaymasterPostOpGasLimit = ""
+	op.PaymasterAndData = ""
+}
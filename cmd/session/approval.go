@@ -0,0 +1,403 @@
+package session
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+)
+
+var (
+	// ErrInvalidApproval is returned when an approval is malformed or its contents do not match its signature.
+	ErrInvalidApproval = errors.New("invalid session key approval")
+	// ErrApprovalExpired is returned when the validity window of an approval has ended.
+	ErrApprovalExpired = errors.New("session key approval expired")
+)
+
+// ApprovalVersion is the approval format version written by Export.
+const ApprovalVersion = 1
+
+// Approval is a portable grant of a session key by the owner of a Kernel account, in the format of the
+// ZeroDev SDK's serializePermissionAccount. It is produced where the owner key lives, e.g. in a browser
+// with the ZeroDev SDK, and imported where the session key is used.
+// Version, KernelVersion, SessionKeyAddress and ValidatorNonce extend the ZeroDev fields; approvals of the
+// ZeroDev SDK have none of them.
+type Approval struct {
+	Version           int              `json:"version,omitempty"`           // Format version, ApprovalVersion, absent in approvals of the ZeroDev SDK
+	KernelVersion     string           `json:"kernelVersion,omitempty"`     // Kernel version of the account, that of the InitCode factory when absent
+	SessionKeyAddress string           `json:"sessionKeyAddress,omitempty"` // Address of the session key, required unless PrivateKey is set
+	ValidatorNonce    *uint32          `json:"validatorNonce,omitempty"`    // Validation nonce the enable signature was produced for
+	PermissionParams  PermissionParams `json:"permissionParams"`
+	Action            ApprovalAction   `json:"action"`
+	ValidityData      ValidityData     `json:"validityData"`
+	AccountParams     AccountParams    `json:"accountParams"`
+	EnableSignature   string           `json:"enableSignature,omitempty"` // Empty when IsPreInstalled is set
+	PrivateKey        string           `json:"privateKey,omitempty"`      // Session key, only set when the holder of the approval also holds the key
+	IsPreInstalled    bool             `json:"isPreInstalled"`            // Set when the permission is already installed on the account
+}
+
+// PermissionParams are the policies of the approved permission.
+type PermissionParams struct {
+	PermissionID string         `json:"permissionId,omitempty"`
+	Policies     []PolicyParams `json:"policies"`
+}
+
+// ApprovalAction is the Kernel function the permission is granted, execute(bytes32,bytes) without a hook.
+type ApprovalAction struct {
+	Address  string        `json:"address"`
+	Selector string        `json:"selector"`
+	Hook     *ApprovalHook `json:"hook,omitempty"`
+}
+
+// ApprovalHook is the hook module of an action.
+type ApprovalHook struct {
+	Address string `json:"address"`
+}
+
+// ValidityData is the plugin validity window, unused by Kernel v3 and zero.
+type ValidityData struct {
+	ValidAfter uint64 `json:"validAfter"`
+	ValidUntil uint64 `json:"validUntil"`
+}
+
+// AccountParams identify the Kernel account: its address and the initCode deploying it.
+type AccountParams struct {
+	InitCode       string `json:"initCode"`
+	AccountAddress string `json:"accountAddress"`
+}
+
+// Approve signs the enable digest of the session key with root, the owner of the ECDSA root validator of
+// the Kernel account at index, and returns the resulting approval.
+func (k *SessionKey) Approve(ctx context.Context, root signer.Signer, version constants.KernelVersion, index *big.Int, chainID uint64, validatorNonce uint32) (*Approval, error) {
+	policies := make([]PolicyParams, 0, len(k.policies))
+	for i, policy := range k.policies {
+		params, err := policy.Params()
+		if err != nil {
+			return nil, fmt.Errorf("policy %d: %w", i, err)
+		}
+		policies = append(policies, params)
+	}
+
+	owner := root.Address().Hex()
+	account, err := kernel.ComputeKernelAddress(version, constants.ECDSAValidatorAddress, owner, index)
+	if err != nil {
+		return nil, err
+	}
+	factory, factoryData, err := kernel.GetKernelFactoryData(version, constants.ECDSAValidatorAddress, owner, index)
+	if err != nil {
+		return nil, err
+	}
+
+	enableSignature, err := k.SignEnable(ctx, root, version, account.Hex(), chainID, validatorNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Approval{
+		Version:           ApprovalVersion,
+		KernelVersion:     string(version),
+		SessionKeyAddress: k.address.Hex(),
+		ValidatorNonce:    &validatorNonce,
+		PermissionParams: PermissionParams{
+			PermissionID: hexutil.Encode(k.permissionID[:]),
+			Policies:     policies,
+		},
+		Action: ApprovalAction{
+			Address:  common.Address{}.Hex(),
+			Selector: hexutil.Encode(executeSelector),
+		},
+		AccountParams: AccountParams{
+			InitCode:       factory + strings.TrimPrefix(factoryData, "0x"),
+			AccountAddress: account.Hex(),
+		},
+		EnableSignature: hexutil.Encode(enableSignature),
+	}, nil
+}
+
+// Export serializes an approval as base64-encoded JSON, optionally embedding the session key's private key.
+func Export(approval *Approval, privateKey *ecdsa.PrivateKey) (string, error) {
+	exported := *approval
+	exported.Version = ApprovalVersion
+	exported.PrivateKey = ""
+	if privateKey != nil {
+		k, err := exported.sessionKey(signer.NewPrivateKeySigner(privateKey))
+		if err != nil {
+			return "", err
+		}
+		exported.SessionKeyAddress = k.Address().Hex()
+		exported.PrivateKey = hexutil.Encode(crypto.FromECDSA(privateKey))
+	}
+
+	encoded, err := json.Marshal(&exported)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal approval: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+// Import parses an approval serialized by Export or the ZeroDev SDK and validates it at the current time.
+// The enable signature is only checked by ImportVerified and VerifyEnableSignature, since it depends on the chain.
+func Import(serialized string) (*Approval, error) {
+	decoded, err := base64.StdEncoding.DecodeString(serialized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not base64: %v", ErrInvalidApproval, err)
+	}
+
+	var approval Approval
+	if err := json.Unmarshal(decoded, &approval); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidApproval, err)
+	}
+	if err := approval.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// ImportVerified imports an approval like Import, and checks that its enable signature installs the session key
+// on its account on chainID and was produced by owner, the owner of the account's ECDSA root validator.
+// The approval must record the validator nonce it was signed for; otherwise use VerifyEnableSignature.
+func ImportVerified(serialized string, chainID uint64, owner common.Address) (*Approval, error) {
+	approval, err := Import(serialized)
+	if err != nil {
+		return nil, err
+	}
+	if approval.ValidatorNonce == nil {
+		return nil, fmt.Errorf("%w: approval does not record its validator nonce", ErrInvalidApproval)
+	}
+	if err := approval.VerifyEnableSignature(chainID, *approval.ValidatorNonce, owner); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// Validate checks that the approval is well formed and valid at now: its account address must be the one
+// deployed by its initCode, and its permission ID, required unless the permission is pre-installed, must
+// match its policies and session key. Returns an error wrapping ErrInvalidApproval or ErrApprovalExpired.
+// The enable signature depends on the chain and is checked by VerifyEnableSignature.
+func (a *Approval) Validate(now time.Time) error {
+	if a.Version != 0 && a.Version != ApprovalVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidApproval, a.Version)
+	}
+	if a.KernelVersion != "" {
+		if _, err := constants.GetKernelAddresses(constants.KernelVersion(a.KernelVersion)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidApproval, err)
+		}
+	}
+	if _, err := a.kernelVersion(); err != nil {
+		return err
+	}
+	if !sameAddress(a.Action.Address, common.Address{}) || !strings.EqualFold(a.Action.Selector, hexutil.Encode(executeSelector)) {
+		return fmt.Errorf("%w: unsupported action %s %s", ErrInvalidApproval, a.Action.Address, a.Action.Selector)
+	}
+	if a.Action.Hook != nil && !sameAddress(a.Action.Hook.Address, common.Address{}) {
+		return fmt.Errorf("%w: unsupported action hook %s", ErrInvalidApproval, a.Action.Hook.Address)
+	}
+	if !a.IsPreInstalled {
+		if _, err := a.EnableSignatureBytes(); err != nil {
+			return err
+		}
+	}
+
+	k, err := a.approvedKey()
+	if err != nil {
+		return err
+	}
+
+	if validUntil, ok := validUntil(k.policies); ok && now.After(validUntil) {
+		return fmt.Errorf("%w: valid until %s", ErrApprovalExpired, validUntil.UTC().Format(time.RFC3339))
+	}
+	if a.ValidityData.ValidUntil != 0 && now.Unix() > int64(a.ValidityData.ValidUntil) {
+		return fmt.Errorf("%w: valid until %s", ErrApprovalExpired, time.Unix(int64(a.ValidityData.ValidUntil), 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// VerifyEnableSignature checks that the enable signature installs the approved session key on the approval's
+// account on chainID, and that it was produced by owner, the owner of the account's ECDSA root validator.
+// validatorNonce is the account's validation nonce when the approval was signed, see ValidatorNonce.
+func (a *Approval) VerifyEnableSignature(chainID uint64, validatorNonce uint32, owner common.Address) error {
+	if a.IsPreInstalled {
+		return fmt.Errorf("%w: pre-installed permission has no enable signature", ErrInvalidApproval)
+	}
+	enableSignature, err := a.EnableSignatureBytes()
+	if err != nil {
+		return err
+	}
+	version, err := a.kernelVersion()
+	if err != nil {
+		return err
+	}
+	k, err := a.approvedKey()
+	if err != nil {
+		return err
+	}
+
+	hash, err := k.EnableHash(version, a.Account(), chainID, validatorNonce)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidApproval, err)
+	}
+	if !signedBy(hash, enableSignature, owner) {
+		return fmt.Errorf("%w: enable signature was not produced by owner %s", ErrInvalidApproval, owner.Hex())
+	}
+	return nil
+}
+
+// SessionKey returns the approved session key backed by s. When s is nil, the private key embedded in the
+// approval is used. Fails when the approval's permission ID does not match its policies and s.
+func (a *Approval) SessionKey(s signer.Signer) (*SessionKey, error) {
+	if s == nil {
+		if a.PrivateKey == "" {
+			return nil, fmt.Errorf("approval does not embed the session key private key")
+		}
+		key, err := signer.NewPrivateKeySignerFromHex(a.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid private key: %v", ErrInvalidApproval, err)
+		}
+		s = key
+	}
+	return a.sessionKey(s)
+}
+
+// Account returns the address of the Kernel account granting the session key.
+func (a *Approval) Account() common.Address {
+	return common.HexToAddress(a.AccountParams.AccountAddress)
+}
+
+// EnableSignatureBytes returns the decoded enable signature, for use with SessionKey.SignUserOpWithEnable.
+func (a *Approval) EnableSignatureBytes() ([]byte, error) {
+	signature, err := hexutil.Decode(a.EnableSignature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid enable signature: %v", ErrInvalidApproval, err)
+	}
+	return signature, nil
+}
+
+// sessionKey rebuilds the session key of the approval signing with s, and checks it against the approval.
+func (a *Approval) sessionKey(s signer.Signer) (*SessionKey, error) {
+	if a.SessionKeyAddress != "" && !sameAddress(a.SessionKeyAddress, s.Address()) {
+		return nil, fmt.Errorf("%w: approval is for session key %s, not %s", ErrInvalidApproval, a.SessionKeyAddress, s.Address().Hex())
+	}
+	return a.checkedKey(s, s.Address())
+}
+
+// approvedKey rebuilds the session key of the approval, without a signer, from its embedded private key or its
+// session key address, and checks its permission ID.
+func (a *Approval) approvedKey() (*SessionKey, error) {
+	var address common.Address
+	switch {
+	case a.PrivateKey != "":
+		key, err := signer.NewPrivateKeySignerFromHex(a.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid private key: %v", ErrInvalidApproval, err)
+		}
+		if a.SessionKeyAddress != "" && !sameAddress(a.SessionKeyAddress, key.Address()) {
+			return nil, fmt.Errorf("%w: private key does not match session key %s", ErrInvalidApproval, a.SessionKeyAddress)
+		}
+		address = key.Address()
+	case common.IsHexAddress(a.SessionKeyAddress):
+		address = common.HexToAddress(a.SessionKeyAddress)
+	default:
+		return nil, fmt.Errorf("%w: approval identifies no session key", ErrInvalidApproval)
+	}
+	return a.checkedKey(nil, address)
+}
+
+// checkedKey rebuilds the session key of address from the approval's policies, and checks that the permission ID,
+// required unless the permission is pre-installed, is derived from them.
+func (a *Approval) checkedKey(s signer.Signer, address common.Address) (*SessionKey, error) {
+	policies, err := a.policies()
+	if err != nil {
+		return nil, err
+	}
+	k, err := newSessionKey(s, address, policies)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidApproval, err)
+	}
+
+	if a.PermissionParams.PermissionID == "" {
+		if !a.IsPreInstalled {
+			return nil, fmt.Errorf("%w: missing permission id", ErrInvalidApproval)
+		}
+		return k, nil
+	}
+	if permissionID := k.PermissionID(); !strings.EqualFold(hexutil.Encode(permissionID[:]), a.PermissionParams.PermissionID) {
+		return nil, fmt.Errorf("%w: permission id %s does not match policies and session key %s", ErrInvalidApproval, a.PermissionParams.PermissionID, address.Hex())
+	}
+	return k, nil
+}
+
+// kernelVersion returns the Kernel version of the approval's account, after checking that its address is the one
+// deployed by its initCode.
+func (a *Approval) kernelVersion() (constants.KernelVersion, error) {
+	if !common.IsHexAddress(a.AccountParams.AccountAddress) {
+		return "", fmt.Errorf("%w: invalid account address %q", ErrInvalidApproval, a.AccountParams.AccountAddress)
+	}
+	initCode, err := hexutil.Decode(a.AccountParams.InitCode)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid initCode: %v", ErrInvalidApproval, err)
+	}
+	version, account, err := kernel.ParseInitCode(initCode)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid initCode: %v", ErrInvalidApproval, err)
+	}
+	if a.KernelVersion != "" && constants.KernelVersion(a.KernelVersion) != version {
+		return "", fmt.Errorf("%w: initCode deploys a Kernel %s account, not %s", ErrInvalidApproval, version, a.KernelVersion)
+	}
+	if account != a.Account() {
+		return "", fmt.Errorf("%w: initCode deploys %s, not account %s", ErrInvalidApproval, account.Hex(), a.AccountParams.AccountAddress)
+	}
+	return version, nil
+}
+
+// policies rebuilds the policies of the approval from their parameters.
+func (a *Approval) policies() ([]Policy, error) {
+	if len(a.PermissionParams.Policies) == 0 {
+		return nil, fmt.Errorf("%w: no policies", ErrInvalidApproval)
+	}
+	policies := make([]Policy, 0, len(a.PermissionParams.Policies))
+	for i, params := range a.PermissionParams.Policies {
+		policy, err := PolicyFromParams(params)
+		if err != nil {
+			return nil, fmt.Errorf("%w: policy %d: %v", ErrInvalidApproval, i, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// sameAddress reports whether a is a valid address equal to b.
+func sameAddress(a string, b common.Address) bool {
+	return common.IsHexAddress(a) && common.HexToAddress(a) == b
+}
+
+// signedBy reports whether signature is a raw or personal_sign signature of hash by address,
+// the two forms the ECDSA root validator accepts.
+func signedBy(hash common.Hash, signature []byte, address common.Address) bool {
+	if len(signature) != 65 {
+		return false
+	}
+	sig := append([]byte{}, signature...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	for _, digest := range [][]byte{hash.Bytes(), accounts.TextHash(hash.Bytes())} {
+		pubKey, err := crypto.SigToPub(digest, sig)
+		if err == nil && crypto.PubkeyToAddress(*pubKey) == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+)
+
+// jsApproval is a serializePermissionAccount payload in the layout written by the ZeroDev SDK, granting an
+// ERC-20 transfer to 0x2222… of less than 1000000 units, until 2100-01-01, with a 1 ETH gas allowance,
+// on the Kernel v0.3.1 account of ECDSA owner 0x4444….
+const jsApproval = `{"permissionParams":{"permissionId":"0x3a459262","policies":[` +
+	`{"type":"call","policyAddress":"0x9a52283276A0ec8740DF50bF01B28A80D880eaf2","policyFlag":"0x0000","permissions":[` +
+	`{"target":"0x1111111111111111111111111111111111111111","valueLimit":"0",` +
+	`"abi":[{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}],` +
+	`"functionName":"transfer","args":[{"condition":0,"value":"0x2222222222222222222222222222222222222222"},{"condition":2,"value":"1000000"}]}],` +
+	`"policyVersion":"0.0.4"},` +
+	`{"type":"timestamp","policyAddress":"0xB9f8f524bE6EcD8C945b1b87f9ae5C192FdCE20F","policyFlag":"0x0000","validAfter":0,"validUntil":4102444800},` +
+	`{"type":"gas","policyAddress":"0xaeFC5AbC67FfD258abD0A3E54f65E70326F84b23","policyFlag":"0x0000","allowed":"1000000000000000000","enforcePaymaster":false,"allowedPaymaster":"0x0000000000000000000000000000000000000000"}]},` +
+	`"action":{"address":"0x0000000000000000000000000000000000000000","selector":"0xe9ae5c53"},` +
+	`"validityData":{"validAfter":0,"validUntil":0},` +
+	`"accountParams":{"initCode":"` + jsInitCode + `","accountAddress":"` + jsAccount + `"},` +
+	`"enableSignature":"0x` + "11111111111111111111111111111111111111111111111111111111111111112222222222222222222222222222222222222222222222222222222222222222" + `1b",` +
+	`"privateKey":"0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",` +
+	`"isPreInstalled":false}`
+
+// The expected permission ID and call policy data were computed with an independent implementation
+// of the ZeroDev SDK encodings.
+const (
+	jsAccount           = "0xaF036DAa5E82221F96f5c992bd9ba0e62C70aA02"
+	jsSessionKeyAddress = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	jsPermissionID      = "0x3a459262"
+	jsInitCode          = "0xd703aaE79538628d27099B8c4f621bE4CCd142d5c5265d5d000000000000000000000000aac5d4240af87249b3f71bc8e4a2cae074a3e4190000000000000000000000000000000000000000000000000000000000000060000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001243c3b752b01845adb2c711129d4f3966735ed98a9f09fc4ce570000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000000e00000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000001444444444444444444444444444444444444444440000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"
+	jsCallPolicyData    = "0x" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000001111111111111111111111111111111111111111" +
+		"a9059cbb00000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000e0" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000002222222222222222222222222222222222222222" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"00000000000000000000000000000000000000000000000000000000000f4240"
+)
+
+func TestImportJSApproval(t *testing.T) {
+	approval, err := Import(base64.StdEncoding.EncodeToString([]byte(jsApproval)))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	k, err := approval.SessionKey(nil)
+	if err != nil {
+		t.Fatalf("SessionKey() error = %v", err)
+	}
+	if k.Address() != common.HexToAddress(jsSessionKeyAddress) {
+		t.Errorf("SessionKey() address = %s, want %s", k.Address().Hex(), jsSessionKeyAddress)
+	}
+	if permissionID := k.PermissionID(); hexutil.Encode(permissionID[:]) != jsPermissionID {
+		t.Errorf("PermissionID() = %x, want %s", permissionID, jsPermissionID)
+	}
+	if got := hexutil.Encode(k.Policies()[0].Data); got != jsCallPolicyData {
+		t.Errorf("call policy data = %s, want %s", got, jsCallPolicyData)
+	}
+	if validUntil, ok := k.ValidUntil(); !ok || validUntil.Unix() != 4102444800 {
+		t.Errorf("ValidUntil() = %s, %v, want 2100-01-01", validUntil, ok)
+	}
+	if approval.Account() != common.HexToAddress(jsAccount) {
+		t.Errorf("Account() = %s", approval.Account().Hex())
+	}
+	if err := approval.Validate(time.Unix(4102444801, 0)); !errors.Is(err, ErrApprovalExpired) {
+		t.Errorf("Validate() after 2100-01-01 error = %v, want %v", err, ErrApprovalExpired)
+	}
+}
+
+func TestImportInvalidApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr error
+	}{
+		{"permission id mismatch", strings.Replace(jsApproval, jsPermissionID, "0x01020304", 1), ErrInvalidApproval},
+		{"missing permission id", strings.Replace(jsApproval, `"permissionId":"`+jsPermissionID+`",`, "", 1), ErrInvalidApproval},
+		{"widened policy", strings.Replace(jsApproval, `"value":"1000000"`, `"value":"1000000000"`, 1), ErrInvalidApproval},
+		{"widened policy without permission id", strings.Replace(strings.Replace(jsApproval, `"allowed":"1000000000000000000"`, `"allowed":"1000000000000000000000"`, 1), `"permissionId":"`+jsPermissionID+`",`, "", 1), ErrInvalidApproval},
+		{"another account", strings.Replace(jsApproval, jsAccount, "0x3333333333333333333333333333333333333333", 1), ErrInvalidApproval},
+		{"another initCode", strings.Replace(jsApproval, "14444444444444444444444444444444444444444", "15555555555555555555555555555555555555555", 1), ErrInvalidApproval},
+		{"invalid initCode", strings.Replace(jsApproval, jsInitCode, "0xd703aae79538628d27099b8c4f621be4ccd142d5c5265d5d", 1), ErrInvalidApproval},
+		{"another kernel version", strings.Replace(jsApproval, `{"permissionParams"`, `{"kernelVersion":"0.3.3","permissionParams"`, 1), ErrInvalidApproval},
+		{"no session key", strings.Replace(jsApproval, `"privateKey"`, `"unused"`, 1), ErrInvalidApproval},
+		{"another session key", strings.Replace(jsApproval, `{"permissionParams"`, `{"sessionKeyAddress":"0x3333333333333333333333333333333333333333","permissionParams"`, 1), ErrInvalidApproval},
+		{"unsupported action", strings.Replace(jsApproval, `"0xe9ae5c53"`, `"0x12345678"`, 1), ErrInvalidApproval},
+		{"unsupported policy", strings.Replace(jsApproval, `"type":"gas"`, `"type":"signature-caller"`, 1), ErrInvalidApproval},
+		{"unknown function", strings.Replace(jsApproval, `"functionName":"transfer"`, `"functionName":"approve"`, 1), ErrInvalidApproval},
+		{"missing enable signature", strings.Replace(jsApproval, `"enableSignature"`, `"unused"`, 1), ErrInvalidApproval},
+		{"unknown version", strings.Replace(jsApproval, `{"permissionParams"`, `{"version":2,"permissionParams"`, 1), ErrInvalidApproval},
+		{"unknown kernel version", strings.Replace(jsApproval, `{"permissionParams"`, `{"kernelVersion":"0.2.4","permissionParams"`, 1), ErrInvalidApproval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Import(base64.StdEncoding.EncodeToString([]byte(tt.json))); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Import() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApprovalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	rootKey, _ := crypto.GenerateKey()
+	root := signer.NewPrivateKeySigner(rootKey)
+
+	callPolicy, err := CallPolicy(CallPermission{
+		Target:     common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Selector:   [4]byte{0xa9, 0x05, 0x9c, 0xbb},
+		ValueLimit: big.NewInt(1000),
+	})
+	if err != nil {
+		t.Fatalf("CallPolicy() error = %v", err)
+	}
+	gasPolicy, err := GasPolicy(big.NewInt(1e18), true, common.HexToAddress("0x4444444444444444444444444444444444444444"))
+	if err != nil {
+		t.Fatalf("GasPolicy() error = %v", err)
+	}
+	rateLimitPolicy, err := RateLimitPolicy(time.Hour, 10, time.Time{})
+	if err != nil {
+		t.Fatalf("RateLimitPolicy() error = %v", err)
+	}
+	timestampPolicy, err := TimestampPolicy(time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("TimestampPolicy() error = %v", err)
+	}
+	sudoPolicy := SudoPolicy()
+	sudoPolicy.Flag = PolicyFlagSkipSignature
+
+	k, privateKey, err := GenerateSessionKey(callPolicy, gasPolicy, rateLimitPolicy, timestampPolicy, sudoPolicy)
+	if err != nil {
+		t.Fatalf("GenerateSessionKey() error = %v", err)
+	}
+	approval, err := k.Approve(ctx, root, constants.KernelVersion033, big.NewInt(0), 8453, 1)
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	serialized, err := Export(approval, privateKey)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	imported, err := Import(serialized)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if imported.Version != ApprovalVersion || imported.KernelVersion != string(constants.KernelVersion033) {
+		t.Errorf("Import() version %d for Kernel %q, want %d for Kernel %s", imported.Version, imported.KernelVersion, ApprovalVersion, constants.KernelVersion033)
+	}
+	importedKey, err := imported.SessionKey(nil)
+	if err != nil {
+		t.Fatalf("SessionKey() error = %v", err)
+	}
+	if importedKey.PermissionID() != k.PermissionID() || importedKey.Address() != k.Address() {
+		t.Errorf("SessionKey() permission %x of %s, want %x of %s", importedKey.PermissionID(), importedKey.Address().Hex(), k.PermissionID(), k.Address().Hex())
+	}
+	if string(importedKey.EnableData()) != string(k.EnableData()) {
+		t.Errorf("SessionKey() enable data = %x, want %x", importedKey.EnableData(), k.EnableData())
+	}
+
+	if _, err := ImportVerified(serialized, 8453, root.Address()); err != nil {
+		t.Errorf("ImportVerified() error = %v", err)
+	}
+
+	// Without the private key, the approval identifies the session key by its address.
+	withoutKey, err := Export(approval, nil)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	imported, err = ImportVerified(withoutKey, 8453, root.Address())
+	if err != nil {
+		t.Fatalf("ImportVerified() without private key error = %v", err)
+	}
+	if _, err := imported.SessionKey(root); !errors.Is(err, ErrInvalidApproval) {
+		t.Errorf("SessionKey() with another signer error = %v, want %v", err, ErrInvalidApproval)
+	}
+
+	otherNonce := uint32(2)
+	tests := []struct {
+		name    string
+		chainID uint64
+		owner   common.Address
+		tamper  func(a *Approval)
+	}{
+		{"another chain", 1, root.Address(), func(a *Approval) {}},
+		{"another owner", 8453, k.Address(), func(a *Approval) {}},
+		{"enable signature", 8453, root.Address(), func(a *Approval) {
+			a.EnableSignature = strings.Replace(a.EnableSignature, "0x", "0x00", 1)[:len(a.EnableSignature)]
+		}},
+		{"validator nonce", 8453, root.Address(), func(a *Approval) { a.ValidatorNonce = &otherNonce }},
+		{"missing validator nonce", 8453, root.Address(), func(a *Approval) { a.ValidatorNonce = nil }},
+		{"widened policies", 8453, root.Address(), func(a *Approval) { a.PermissionParams.Policies[1].Allowed = "1000000000000000000000" }},
+		{"widened policies without permission id", 8453, root.Address(), func(a *Approval) {
+			a.PermissionParams.Policies[1].Allowed = "1000000000000000000000"
+			a.PermissionParams.PermissionID = ""
+		}},
+		{"removed policy", 8453, root.Address(), func(a *Approval) { a.PermissionParams.Policies = a.PermissionParams.Policies[1:] }},
+		{"session key", 8453, root.Address(), func(a *Approval) { a.SessionKeyAddress = root.Address().Hex() }},
+		{"account", 8453, root.Address(), func(a *Approval) { a.AccountParams.AccountAddress = k.Address().Hex() }},
+		{"initCode", 8453, root.Address(), func(a *Approval) {
+			a.AccountParams.InitCode = strings.Replace(a.AccountParams.InitCode, strings.ToLower(root.Address().Hex()[2:]), strings.ToLower(k.Address().Hex()[2:]), 1)
+		}},
+		{"kernel version", 8453, root.Address(), func(a *Approval) { a.KernelVersion = string(constants.KernelVersion032) }},
+		{"version", 8453, root.Address(), func(a *Approval) { a.Version = ApprovalVersion + 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, _ := base64.StdEncoding.DecodeString(withoutKey)
+			var tampered Approval
+			if err := json.Unmarshal(decoded, &tampered); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			tt.tamper(&tampered)
+			encoded, _ := json.Marshal(&tampered)
+
+			if _, err := ImportVerified(base64.StdEncoding.EncodeToString(encoded), tt.chainID, tt.owner); !errors.Is(err, ErrInvalidApproval) {
+				t.Errorf("ImportVerified() error = %v, want %v", err, ErrInvalidApproval)
+			}
+		})
+	}
+}
+
+func TestApproveCallPolicyWithRules(t *testing.T) {
+	policy, err := CallPolicy(CallPermission{
+		Target:   common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Selector: [4]byte{0xa9, 0x05, 0x9c, 0xbb},
+		Rules:    []ParamRule{{Condition: ParamConditionEqual, Params: []common.Hash{{0x01}}}},
+	})
+	if err != nil {
+		t.Fatalf("CallPolicy() error = %v", err)
+	}
+	k, _, err := GenerateSessionKey(policy)
+	if err != nil {
+		t.Fatalf("GenerateSessionKey() error = %v", err)
+	}
+
+	rootKey, _ := crypto.GenerateKey()
+	if _, err := k.Approve(context.Background(), signer.NewPrivateKeySigner(rootKey), constants.KernelVersion033, nil, 8453, 1); err == nil {
+		t.Error("Approve() succeeded for a call policy with rules that cannot be serialized")
+	}
+}
@@ -0,0 +1,46 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/nonce"
+)
+
+var (
+	uint32Args           = abi.Arguments{{Type: uint32Type}}
+	currentNonceSelector = crypto.Keccak256([]byte("currentNonce()"))[:4]
+)
+
+// ValidatorNonce returns the validation nonce of a Kernel account to use in EnableHash.
+// Accounts that are not deployed yet start at 1.
+func ValidatorNonce(ctx context.Context, caller bind.ContractCaller, account common.Address) (uint32, error) {
+	code, err := caller.CodeAt(ctx, account, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get code at %s: %w", account.Hex(), err)
+	}
+	if len(code) == 0 {
+		return 1, nil
+	}
+
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &account, Data: currentNonceSelector}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call currentNonce: %w", err)
+	}
+	values, err := uint32Args.Unpack(result)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode currentNonce result: %w", err)
+	}
+	return values[0].(uint32), nil
+}
+
+// GetNonce returns the next EntryPoint nonce of account for the given nonce key, e.g. a session key's NonceKey.
+func GetNonce(ctx context.Context, caller bind.ContractCaller, entryPoint, account common.Address, key *big.Int) (*big.Int, error) {
+	return nonce.NewEntryPointBackend(caller, entryPoint).GetNonce(ctx, account, key)
+}
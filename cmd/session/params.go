@@ -0,0 +1,372 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// Policy types of the ZeroDev SDK policy parameters
+const (
+	PolicyTypeSudo      = "sudo"
+	PolicyTypeCall      = "call"
+	PolicyTypeGas       = "gas"
+	PolicyTypeRateLimit = "rate-limit"
+	PolicyTypeTimestamp = "timestamp"
+)
+
+// callPolicyVersion is the ZeroDev SDK call policy version deployed at constants.CallPolicyAddress.
+const callPolicyVersion = "0.0.4"
+
+// policyAddresses are the policy modules the ZeroDev SDK uses when the parameters do not name one.
+var policyAddresses = map[string]string{
+	PolicyTypeSudo:      constants.SudoPolicyAddress,
+	PolicyTypeCall:      constants.CallPolicyAddress,
+	PolicyTypeGas:       constants.GasPolicyAddress,
+	PolicyTypeRateLimit: constants.RateLimitPolicyAddress,
+	PolicyTypeTimestamp: constants.TimestampPolicyAddress,
+}
+
+// PolicyParams are the parameters the ZeroDev SDK serializes for a policy, from which it rebuilds the
+// policy install data. Fields not used by Type are omitted.
+type PolicyParams struct {
+	Type          string `json:"type"`
+	PolicyAddress string `json:"policyAddress,omitempty"`
+	PolicyFlag    string `json:"policyFlag,omitempty"` // 2-byte hex PolicyFlag
+
+	// Call policy
+	PolicyVersion string                 `json:"policyVersion,omitempty"`
+	Permissions   []CallPermissionParams `json:"permissions,omitempty"`
+
+	// Gas policy
+	Allowed          string `json:"allowed,omitempty"` // Decimal wei amount
+	EnforcePaymaster *bool  `json:"enforcePaymaster,omitempty"`
+	AllowedPaymaster string `json:"allowedPaymaster,omitempty"`
+
+	// Rate limit policy, in seconds
+	Interval *uint64 `json:"interval,omitempty"`
+	Count    *uint64 `json:"count,omitempty"`
+	StartAt  *uint64 `json:"startAt,omitempty"`
+
+	// Timestamp policy, in Unix seconds
+	ValidAfter *uint64 `json:"validAfter,omitempty"`
+	ValidUntil *uint64 `json:"validUntil,omitempty"`
+}
+
+// CallPermissionParams is a call policy permission in the ZeroDev SDK format. When ABI is set, the selector
+// is the one of FunctionName and Args constrain its arguments; otherwise Selector is used as is.
+type CallPermissionParams struct {
+	CallType     string          `json:"callType,omitempty"` // 1-byte hex kernel.CallType
+	Target       string          `json:"target"`
+	Selector     string          `json:"selector,omitempty"`
+	ValueLimit   string          `json:"valueLimit,omitempty"` // Decimal wei amount
+	ABI          json.RawMessage `json:"abi,omitempty"`
+	FunctionName string          `json:"functionName,omitempty"`
+	Args         []*ArgParams    `json:"args,omitempty"`
+}
+
+// ArgParams constrains the argument of the same index; a nil entry leaves the argument unconstrained.
+// Value is a single JSON value, or an array of values for ParamConditionOneOf.
+type ArgParams struct {
+	Condition ParamCondition  `json:"condition"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// Params returns the ZeroDev SDK parameters of the policy.
+// Fails for policies built without parameters, e.g. a CallPolicy with argument rules.
+func (p Policy) Params() (PolicyParams, error) {
+	if p.params == nil {
+		return PolicyParams{}, fmt.Errorf("policy %s cannot be expressed in the ZeroDev SDK format", p.Address.Hex())
+	}
+
+	params := *p.params
+	params.PolicyAddress = p.Address.Hex()
+	params.PolicyFlag = hexutil.Encode([]byte{byte(p.Flag >> 8), byte(p.Flag)})
+
+	rebuilt, err := PolicyFromParams(params)
+	if err != nil {
+		return PolicyParams{}, err
+	}
+	if !bytes.Equal(rebuilt.Data, p.Data) {
+		return PolicyParams{}, fmt.Errorf("policy %s data does not match its parameters", p.Address.Hex())
+	}
+	return params, nil
+}
+
+// PolicyFromParams builds a policy from its ZeroDev SDK parameters, encoding its install data as the SDK does.
+func PolicyFromParams(params PolicyParams) (Policy, error) {
+	defaultAddress, ok := policyAddresses[params.Type]
+	if !ok {
+		return Policy{}, fmt.Errorf("unsupported policy type %q", params.Type)
+	}
+
+	address := common.HexToAddress(defaultAddress)
+	if params.PolicyAddress != "" {
+		if !common.IsHexAddress(params.PolicyAddress) {
+			return Policy{}, fmt.Errorf("invalid policy address: %q", params.PolicyAddress)
+		}
+		address = common.HexToAddress(params.PolicyAddress)
+	}
+
+	var flag PolicyFlag
+	if params.PolicyFlag != "" {
+		raw, err := hexutil.Decode(params.PolicyFlag)
+		if err != nil || len(raw) != 2 {
+			return Policy{}, fmt.Errorf("invalid policy flag: %q", params.PolicyFlag)
+		}
+		flag = PolicyFlag(raw[0])<<8 | PolicyFlag(raw[1])
+	}
+
+	data, err := policyData(params)
+	if err != nil {
+		return Policy{}, fmt.Errorf("%s policy: %w", params.Type, err)
+	}
+
+	stored := params
+	stored.PolicyAddress = ""
+	stored.PolicyFlag = ""
+	return Policy{Address: address, Flag: flag, Data: data, params: &stored}, nil
+}
+
+// policyData encodes the install data of a policy from its parameters.
+func policyData(params PolicyParams) ([]byte, error) {
+	switch params.Type {
+	case PolicyTypeSudo:
+		return nil, nil
+
+	case PolicyTypeCall:
+		if params.PolicyVersion == "0.0.1" {
+			return nil, fmt.Errorf("unsupported call policy version %s", params.PolicyVersion)
+		}
+		permissions := make([]CallPermission, 0, len(params.Permissions))
+		for i, p := range params.Permissions {
+			permission, err := callPermissionFromParams(p)
+			if err != nil {
+				return nil, fmt.Errorf("permission %d: %w", i, err)
+			}
+			permissions = append(permissions, permission)
+		}
+		policy, err := CallPolicy(permissions...)
+		if err != nil {
+			return nil, err
+		}
+		return policy.Data, nil
+
+	case PolicyTypeGas:
+		allowed := new(big.Int)
+		if params.Allowed != "" {
+			var err error
+			if allowed, err = userop.ParseUint256(params.Allowed); err != nil {
+				return nil, fmt.Errorf("invalid allowance: %w", err)
+			}
+		}
+		if allowed.BitLen() > 128 {
+			return nil, fmt.Errorf("gas allowance out of range: %s", allowed)
+		}
+		paymaster := common.Address{}
+		if params.AllowedPaymaster != "" {
+			if !common.IsHexAddress(params.AllowedPaymaster) {
+				return nil, fmt.Errorf("invalid paymaster address: %q", params.AllowedPaymaster)
+			}
+			paymaster = common.HexToAddress(params.AllowedPaymaster)
+		}
+		enforcePaymaster := params.EnforcePaymaster != nil && *params.EnforcePaymaster
+		return packGasPolicy(allowed, enforcePaymaster, paymaster), nil
+
+	case PolicyTypeRateLimit:
+		return packUint48Params(params.Interval, params.Count, params.StartAt)
+
+	case PolicyTypeTimestamp:
+		return packUint48Params(params.ValidAfter, params.ValidUntil)
+
+	default:
+		return nil, fmt.Errorf("unsupported policy type %q", params.Type)
+	}
+}
+
+// packUint48Params packs optional parameters as uint48s, with missing parameters set to 0.
+func packUint48Params(params ...*uint64) ([]byte, error) {
+	values := make([]uint64, len(params))
+	for i, param := range params {
+		if param == nil {
+			continue
+		}
+		if *param > maxUint48 {
+			return nil, fmt.Errorf("value %d does not fit in uint48", *param)
+		}
+		values[i] = *param
+	}
+	return packUint48(values...), nil
+}
+
+// callPermissionFromParams converts a ZeroDev SDK call permission, deriving its selector and argument
+// rules from its ABI when it has one.
+func callPermissionFromParams(params CallPermissionParams) (CallPermission, error) {
+	if !common.IsHexAddress(params.Target) {
+		return CallPermission{}, fmt.Errorf("invalid target address: %q", params.Target)
+	}
+	permission := CallPermission{Target: common.HexToAddress(params.Target)}
+
+	if params.CallType != "" {
+		raw, err := hexutil.Decode(params.CallType)
+		if err != nil || len(raw) != 1 {
+			return CallPermission{}, fmt.Errorf("invalid call type: %q", params.CallType)
+		}
+		permission.CallType = kernel.CallType(raw[0])
+	}
+	if params.ValueLimit != "" {
+		valueLimit, err := userop.ParseUint256(params.ValueLimit)
+		if err != nil {
+			return CallPermission{}, fmt.Errorf("invalid value limit: %w", err)
+		}
+		permission.ValueLimit = valueLimit
+	}
+
+	if len(params.ABI) == 0 {
+		if len(params.Args) > 0 {
+			return CallPermission{}, fmt.Errorf("argument rules require an ABI")
+		}
+		if params.Selector != "" {
+			raw, err := hexutil.Decode(params.Selector)
+			if err != nil || len(raw) != 4 {
+				return CallPermission{}, fmt.Errorf("invalid selector: %q", params.Selector)
+			}
+			permission.Selector = [4]byte(raw)
+		}
+		return permission, nil
+	}
+
+	method, err := findMethod(params.ABI, params.FunctionName)
+	if err != nil {
+		return CallPermission{}, err
+	}
+	permission.Selector = [4]byte(method.ID)
+
+	for i, arg := range params.Args {
+		if arg == nil {
+			continue
+		}
+		if i >= len(method.Inputs) {
+			return CallPermission{}, fmt.Errorf("argument %d: %s has %d arguments", i, method.Sig, len(method.Inputs))
+		}
+
+		values := []json.RawMessage{arg.Value}
+		if arg.Condition == ParamConditionOneOf {
+			if err := json.Unmarshal(arg.Value, &values); err != nil {
+				return CallPermission{}, fmt.Errorf("argument %d: one of condition requires an array of values", i)
+			}
+		}
+
+		rule := ParamRule{Condition: arg.Condition, Offset: ArgOffset(i)}
+		for _, value := range values {
+			word, err := encodeArg(method.Inputs[i].Type, value)
+			if err != nil {
+				return CallPermission{}, fmt.Errorf("argument %d: %w", i, err)
+			}
+			rule.Params = append(rule.Params, word)
+		}
+		permission.Rules = append(permission.Rules, rule)
+	}
+	return permission, nil
+}
+
+// findMethod returns the function named name in a JSON ABI. Overloaded names are rejected.
+func findMethod(rawABI json.RawMessage, name string) (abi.Method, error) {
+	parsed, err := abi.JSON(bytes.NewReader(rawABI))
+	if err != nil {
+		return abi.Method{}, fmt.Errorf("invalid ABI: %w", err)
+	}
+
+	var found []abi.Method
+	for _, method := range parsed.Methods {
+		if method.RawName == name {
+			found = append(found, method)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("function %q not found in ABI", name)
+	case 1:
+		return found[0], nil
+	default:
+		return abi.Method{}, fmt.Errorf("function %q is overloaded", name)
+	}
+}
+
+// encodeArg ABI-encodes a JSON argument value of a static type into a single word.
+func encodeArg(typ abi.Type, raw json.RawMessage) (common.Hash, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid %s value: %w", typ, err)
+	}
+
+	switch typ.T {
+	case abi.AddressTy:
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return common.Hash{}, fmt.Errorf("invalid address value: %s", raw)
+		}
+		return common.BytesToHash(common.HexToAddress(s).Bytes()), nil
+
+	case abi.BoolTy:
+		b, ok := value.(bool)
+		if !ok {
+			return common.Hash{}, fmt.Errorf("invalid bool value: %s", raw)
+		}
+		var word common.Hash
+		if b {
+			word[31] = 1
+		}
+		return word, nil
+
+	case abi.UintTy, abi.IntTy:
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		}
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return common.Hash{}, fmt.Errorf("invalid %s value: %s", typ, raw)
+		}
+		if !fitsInteger(n, typ) {
+			return common.Hash{}, fmt.Errorf("%s value out of range: %s", typ, n)
+		}
+		return common.BytesToHash(math.U256Bytes(n)), nil
+
+	case abi.FixedBytesTy:
+		s, _ := value.(string)
+		b, err := hexutil.Decode(s)
+		if err != nil || len(b) != typ.Size {
+			return common.Hash{}, fmt.Errorf("invalid %s value: %s", typ, raw)
+		}
+		var word common.Hash
+		copy(word[:], b)
+		return word, nil
+
+	default:
+		return common.Hash{}, fmt.Errorf("unsupported argument type %s", typ)
+	}
+}
+
+// fitsInteger reports whether n is in the range of the integer type typ.
+func fitsInteger(n *big.Int, typ abi.Type) bool {
+	if typ.T == abi.UintTy {
+		return n.Sign() >= 0 && n.BitLen() <= typ.Size
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(typ.Size-1))
+	return n.Cmp(new(big.Int).Neg(limit)) >= 0 && n.Cmp(limit) < 0
+}
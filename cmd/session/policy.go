@@ -0,0 +1,274 @@
+package session
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+)
+
+// PolicyFlag selects which checks of a policy the permission validator skips.
+type PolicyFlag uint16
+
+// Supported policy flags
+const (
+	PolicyFlagForAll        PolicyFlag = 0x0000
+	PolicyFlagSkipUserOp    PolicyFlag = 0x0001
+	PolicyFlagSkipSignature PolicyFlag = 0x0002
+)
+
+// Policy is a permission validator policy module and its install data.
+type Policy struct {
+	Address common.Address
+	Flag    PolicyFlag
+	Data    []byte
+
+	params *PolicyParams // ZeroDev SDK serialization parameters, nil when the policy has none
+}
+
+// ParamCondition is the comparison a CallPolicy rule applies to a call argument.
+type ParamCondition uint8
+
+// Supported CallPolicy conditions
+const (
+	ParamConditionEqual              ParamCondition = 0
+	ParamConditionGreaterThan        ParamCondition = 1
+	ParamConditionLessThan           ParamCondition = 2
+	ParamConditionGreaterThanOrEqual ParamCondition = 3
+	ParamConditionLessThanOrEqual    ParamCondition = 4
+	ParamConditionNotEqual           ParamCondition = 5
+	ParamConditionOneOf              ParamCondition = 6
+)
+
+// ParamRule constrains the 32-byte call argument found at Offset bytes after the selector.
+// Conditions other than ParamConditionOneOf take exactly one param.
+type ParamRule struct {
+	Condition ParamCondition
+	Offset    uint64
+	Params    []common.Hash
+}
+
+// CallPermission allows calls with CallType to Target's Selector carrying at most ValueLimit wei.
+// A zero Target matches any contract, and a nil ValueLimit forbids sending value.
+type CallPermission struct {
+	CallType   kernel.CallType
+	Target     common.Address
+	Selector   [4]byte
+	ValueLimit *big.Int
+	Rules      []ParamRule
+}
+
+// callPermission mirrors the CallPolicy Permission struct.
+type callPermission struct {
+	CallType   [1]byte
+	Target     common.Address
+	Selector   [4]byte
+	ValueLimit *big.Int
+	Rules      []paramRule
+}
+
+// paramRule mirrors the CallPolicy ParamRule struct.
+type paramRule struct {
+	Condition uint8
+	Offset    uint64
+	Params    [][32]byte
+}
+
+var (
+	permissionsType, _ = abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "callType", Type: "bytes1"},
+		{Name: "target", Type: "address"},
+		{Name: "selector", Type: "bytes4"},
+		{Name: "valueLimit", Type: "uint256"},
+		{Name: "rules", Type: "tuple[]", Components: []abi.ArgumentMarshaling{
+			{Name: "condition", Type: "uint8"},
+			{Name: "offset", Type: "uint64"},
+			{Name: "params", Type: "bytes32[]"},
+		}},
+	})
+	permissionsArgs = abi.Arguments{{Type: permissionsType}}
+)
+
+// maxUint48 bounds the timestamps and counters packed as uint48 by the policies.
+const maxUint48 = 1<<48 - 1
+
+// ArgOffset returns the ParamRule offset of the static argument at index.
+func ArgOffset(index int) uint64 {
+	return uint64(index) * 32
+}
+
+// SudoPolicy returns a policy allowing any user operation, leaving all restrictions to the other policies.
+func SudoPolicy() Policy {
+	return Policy{
+		Address: common.HexToAddress(constants.SudoPolicyAddress),
+		params:  &PolicyParams{Type: PolicyTypeSudo},
+	}
+}
+
+// CallPolicy returns a policy restricting the session key to the given calls.
+// The ZeroDev SDK only expresses argument rules through a contract ABI, so policies with rules
+// cannot be serialized in an Approval unless built from an ABI with PolicyFromParams.
+func CallPolicy(permissions ...CallPermission) (Policy, error) {
+	if len(permissions) == 0 {
+		return Policy{}, fmt.Errorf("call policy requires at least one permission")
+	}
+
+	params := &PolicyParams{Type: PolicyTypeCall, PolicyVersion: callPolicyVersion}
+	encoded := make([]callPermission, 0, len(permissions))
+	for i, permission := range permissions {
+		valueLimit := permission.ValueLimit
+		if valueLimit == nil {
+			valueLimit = new(big.Int)
+		}
+		if valueLimit.Sign() < 0 || valueLimit.BitLen() > 256 {
+			return Policy{}, fmt.Errorf("permission %d: value limit out of range: %s", i, valueLimit)
+		}
+
+		rules := make([]paramRule, 0, len(permission.Rules))
+		for j, rule := range permission.Rules {
+			if rule.Condition > ParamConditionOneOf {
+				return Policy{}, fmt.Errorf("permission %d rule %d: unsupported condition %d", i, j, rule.Condition)
+			}
+			if len(rule.Params) == 0 || (rule.Condition != ParamConditionOneOf && len(rule.Params) != 1) {
+				return Policy{}, fmt.Errorf("permission %d rule %d: invalid number of params: %d", i, j, len(rule.Params))
+			}
+			params := make([][32]byte, len(rule.Params))
+			for k, param := range rule.Params {
+				params[k] = param
+			}
+			rules = append(rules, paramRule{
+				Condition: uint8(rule.Condition),
+				Offset:    rule.Offset,
+				Params:    params,
+			})
+		}
+
+		encoded = append(encoded, callPermission{
+			CallType:   [1]byte{byte(permission.CallType)},
+			Target:     permission.Target,
+			Selector:   permission.Selector,
+			ValueLimit: valueLimit,
+			Rules:      rules,
+		})
+		if len(rules) > 0 {
+			params = nil
+		}
+		if params != nil {
+			params.Permissions = append(params.Permissions, CallPermissionParams{
+				CallType:   hexutil.Encode([]byte{byte(permission.CallType)}),
+				Target:     permission.Target.Hex(),
+				Selector:   hexutil.Encode(permission.Selector[:]),
+				ValueLimit: valueLimit.String(),
+			})
+		}
+	}
+
+	data, err := permissionsArgs.Pack(encoded)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to encode call policy: %w", err)
+	}
+	return Policy{Address: common.HexToAddress(constants.CallPolicyAddress), Data: data, params: params}, nil
+}
+
+// GasPolicy returns a policy capping the total gas cost, in wei, the session key can spend.
+// When enforcePaymaster is set, user operations must be sponsored, by paymaster if it is not zero.
+func GasPolicy(allowed *big.Int, enforcePaymaster bool, paymaster common.Address) (Policy, error) {
+	if allowed == nil || allowed.Sign() < 0 || allowed.BitLen() > 128 {
+		return Policy{}, fmt.Errorf("gas allowance out of range: %v", allowed)
+	}
+
+	return Policy{
+		Address: common.HexToAddress(constants.GasPolicyAddress),
+		Data:    packGasPolicy(allowed, enforcePaymaster, paymaster),
+		params: &PolicyParams{
+			Type:             PolicyTypeGas,
+			Allowed:          allowed.String(),
+			EnforcePaymaster: &enforcePaymaster,
+			AllowedPaymaster: paymaster.Hex(),
+		},
+	}, nil
+}
+
+// RateLimitPolicy returns a policy allowing at most count user operations per interval, starting at startAt.
+// A zero startAt starts immediately.
+func RateLimitPolicy(interval time.Duration, count uint64, startAt time.Time) (Policy, error) {
+	if interval < time.Second || uint64(interval/time.Second) > maxUint48 {
+		return Policy{}, fmt.Errorf("rate limit interval out of range: %s", interval)
+	}
+	if count == 0 || count > maxUint48 {
+		return Policy{}, fmt.Errorf("rate limit count out of range: %d", count)
+	}
+	start, err := unixUint48(startAt)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	seconds := uint64(interval / time.Second)
+	return Policy{
+		Address: common.HexToAddress(constants.RateLimitPolicyAddress),
+		Data:    packUint48(seconds, count, start),
+		params:  &PolicyParams{Type: PolicyTypeRateLimit, Interval: &seconds, Count: &count, StartAt: &start},
+	}, nil
+}
+
+// TimestampPolicy returns a policy restricting the session key to the window [validAfter, validUntil].
+// A zero validAfter or validUntil leaves that side of the window open.
+func TimestampPolicy(validAfter, validUntil time.Time) (Policy, error) {
+	after, err := unixUint48(validAfter)
+	if err != nil {
+		return Policy{}, err
+	}
+	until, err := unixUint48(validUntil)
+	if err != nil {
+		return Policy{}, err
+	}
+	if until == 0 {
+		until = maxUint48
+	}
+	if after >= until {
+		return Policy{}, fmt.Errorf("validAfter %d must be before validUntil %d", after, until)
+	}
+
+	return Policy{
+		Address: common.HexToAddress(constants.TimestampPolicyAddress),
+		Data:    packUint48(after, until),
+		params:  &PolicyParams{Type: PolicyTypeTimestamp, ValidAfter: &after, ValidUntil: &until},
+	}, nil
+}
+
+// unixUint48 converts t to Unix seconds fitting in a uint48, mapping the zero time to 0.
+func unixUint48(t time.Time) (uint64, error) {
+	if t.IsZero() {
+		return 0, nil
+	}
+	if t.Unix() < 0 || t.Unix() > maxUint48 {
+		return 0, fmt.Errorf("timestamp out of range: %s", t)
+	}
+	return uint64(t.Unix()), nil
+}
+
+// packGasPolicy packs the gas policy install data as uint128 allowed || bool enforcePaymaster || address paymaster.
+func packGasPolicy(allowed *big.Int, enforcePaymaster bool, paymaster common.Address) []byte {
+	data := make([]byte, 16, 37)
+	allowed.FillBytes(data)
+	if enforcePaymaster {
+		data = append(data, 0x01)
+	} else {
+		data = append(data, 0x00)
+	}
+	return append(data, paymaster.Bytes()...)
+}
+
+// packUint48 packs values as consecutive big-endian uint48s.
+func packUint48(values ...uint64) []byte {
+	packed := make([]byte, 0, 6*len(values))
+	for _, v := range values {
+		packed = append(packed, byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	return packed
+}
@@ -0,0 +1,308 @@
+// Package session implements session keys for Kernel v3 accounts on the permission validator,
+// scoping a secondary signer to the calls, spend and time window allowed by its policies.
+package session
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/nonce"
+	"github.com/zerodevapp/sdk-go/cmd/signer"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// signerPrefix separates the per-policy signature data from the session key signature.
+const signerPrefix byte = 0xff
+
+var (
+	enableTypeHash = crypto.Keccak256Hash([]byte("Enable(bytes21 validationId,uint32 nonce,address hook,bytes validatorData,bytes hookData,bytes selectorData)"))
+
+	bytesType, _        = abi.NewType("bytes", "", nil)
+	bytesArrayType, _   = abi.NewType("bytes[]", "", nil)
+	bytes21Type, _      = abi.NewType("bytes21", "", nil)
+	bytes32Type, _      = abi.NewType("bytes32", "", nil)
+	uint32Type, _       = abi.NewType("uint32", "", nil)
+	addressType, _      = abi.NewType("address", "", nil)
+	enableDataArgs      = abi.Arguments{{Type: bytesArrayType}}
+	permissionIDArgs    = abi.Arguments{{Type: bytesArrayType}, {Type: bytesType}}
+	enableStructArgs    = abi.Arguments{{Type: bytes32Type}, {Type: bytes21Type}, {Type: uint32Type}, {Type: addressType}, {Type: bytes32Type}, {Type: bytes32Type}, {Type: bytes32Type}}
+	enableSignatureArgs = abi.Arguments{{Type: bytesType}, {Type: bytesType}, {Type: bytesType}, {Type: bytesType}, {Type: bytesType}}
+)
+
+// executeSelector is the Kernel v3 execute(bytes32,bytes) selector the session key is granted.
+var executeSelector = crypto.Keccak256([]byte("execute(bytes32,bytes)"))[:4]
+
+// SessionKey is a signer installed on a Kernel account through the permission validator,
+// restricted by its policies. The ECDSA signer module checks its user operation signatures.
+type SessionKey struct {
+	signer       signer.Signer
+	address      common.Address
+	policies     []Policy
+	enableData   []byte
+	permissionID [4]byte
+}
+
+// NewSessionKey creates a session key for s restricted by policies.
+// The permission ID is derived from the policies and signer as the ZeroDev SDK does, so the same signer and
+// policies always map to the same permission in both SDKs.
+func NewSessionKey(s signer.Signer, policies ...Policy) (*SessionKey, error) {
+	return newSessionKey(s, s.Address(), policies)
+}
+
+// newSessionKey creates a session key for address, signing with s when it is not nil.
+func newSessionKey(s signer.Signer, address common.Address, policies []Policy) (*SessionKey, error) {
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("session key requires at least one policy")
+	}
+
+	enableData, err := encodeEnableData(address, policies)
+	if err != nil {
+		return nil, err
+	}
+	permissionID, err := derivePermissionID(address, policies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionKey{
+		signer:       s,
+		address:      address,
+		policies:     append([]Policy{}, policies...),
+		enableData:   enableData,
+		permissionID: permissionID,
+	}, nil
+}
+
+// GenerateSessionKey creates a session key backed by a freshly generated private key.
+// The private key is returned so it can be stored by the caller.
+func GenerateSessionKey(policies ...Policy) (*SessionKey, *ecdsa.PrivateKey, error) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+	k, err := NewSessionKey(signer.NewPrivateKeySigner(privateKey), policies...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, privateKey, nil
+}
+
+// Address returns the address of the session key signer.
+func (k *SessionKey) Address() common.Address {
+	return k.address
+}
+
+// Policies returns a copy of the policies restricting the session key.
+func (k *SessionKey) Policies() []Policy {
+	return append([]Policy{}, k.policies...)
+}
+
+// ValidUntil returns the end of the validity window set by a TimestampPolicy, if any.
+func (k *SessionKey) ValidUntil() (time.Time, bool) {
+	return validUntil(k.policies)
+}
+
+// validUntil returns the end of the validity window set by a TimestampPolicy among policies, if any.
+func validUntil(policies []Policy) (time.Time, bool) {
+	for _, policy := range policies {
+		if policy.Address != common.HexToAddress(constants.TimestampPolicyAddress) || len(policy.Data) != 12 {
+			continue
+		}
+		validUntil := new(big.Int).SetBytes(policy.Data[6:12]).Int64()
+		if validUntil == 0 || validUntil == maxUint48 {
+			return time.Time{}, false
+		}
+		return time.Unix(validUntil, 0), true
+	}
+	return time.Time{}, false
+}
+
+// PermissionID returns the 4-byte permission identifier of the session key.
+func (k *SessionKey) PermissionID() [4]byte {
+	return k.permissionID
+}
+
+// ValidatorID returns the 21-byte Kernel validation identifier (0x02 || permissionId, zero padded).
+func (k *SessionKey) ValidatorID() [21]byte {
+	var id [21]byte
+	id[0] = byte(kernel.ValidatorTypePermission)
+	copy(id[1:], k.permissionID[:])
+	return id
+}
+
+// EnableData returns the permission validator install data: the ABI-encoded policy and signer
+// entries, each flag || module || init data, with the signer last.
+func (k *SessionKey) EnableData() []byte {
+	return append([]byte{}, k.enableData...)
+}
+
+// NonceKey returns the 192-bit EntryPoint nonce key selecting the session key:
+// mode || 0x02 || permissionId || zero padding || 2-byte key. Enable mode installs the permission
+// within the user operation, default mode uses an already installed permission.
+func (k *SessionKey) NonceKey(enable bool) *big.Int {
+	mode := nonce.ValidationModeDefault
+	if enable {
+		mode = nonce.ValidationModeEnable
+	}
+	return nonce.KernelKey(mode, k.ValidatorID(), 0)
+}
+
+// EnableHash returns the EIP-712 digest the account's root validator signs to install the session key:
+// an Enable struct over the "Kernel" domain of account, with validatorNonce being the account's current validation nonce.
+func (k *SessionKey) EnableHash(version constants.KernelVersion, account common.Address, chainID uint64, validatorNonce uint32) (common.Hash, error) {
+	domainSeparator, err := kernel.DomainSeparator(version, account, chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	encoded, err := enableStructArgs.Pack(
+		enableTypeHash,
+		k.ValidatorID(),
+		validatorNonce,
+		common.Address{},
+		crypto.Keccak256Hash(k.enableData),
+		crypto.Keccak256Hash(nil),
+		crypto.Keccak256Hash(executeSelector),
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode enable struct: %w", err)
+	}
+
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), crypto.Keccak256(encoded)), nil
+}
+
+// SignEnable signs the enable digest of the session key with root, the owner of the account's
+// ECDSA root validator. The signature authorizes installing the session key on account and can be
+// produced where the owner key lives, separately from the session key.
+func (k *SessionKey) SignEnable(ctx context.Context, root signer.Signer, version constants.KernelVersion, account string, chainID uint64, validatorNonce uint32) ([]byte, error) {
+	if !common.IsHexAddress(account) {
+		return nil, fmt.Errorf("invalid account address: %q", account)
+	}
+
+	hash, err := k.EnableHash(version, common.HexToAddress(account), chainID, validatorNonce)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := root.SignHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign enable hash: %w", err)
+	}
+	return signature, nil
+}
+
+// SignUserOpHash signs a user operation hash with the session key in the permission validator format.
+func (k *SessionKey) SignUserOpHash(ctx context.Context, userOpHash common.Hash) ([]byte, error) {
+	if k.signer == nil {
+		return nil, fmt.Errorf("session key %s has no signer", k.address.Hex())
+	}
+	signature, err := k.signer.SignMessage(ctx, userOpHash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign user operation hash: %w", err)
+	}
+	return append([]byte{signerPrefix}, signature...), nil
+}
+
+// SignUserOp recomputes the userOpHash of a builder response locally and signs it with the session key,
+// for an account where the session key is already installed.
+// The user operation nonce must use the default mode NonceKey of the session key.
+func (k *SessionKey) SignUserOp(ctx context.Context, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64) (string, error) {
+	signature, err := k.signUserOp(ctx, op, entryPointVersion, chainID, false)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(signature), nil
+}
+
+// SignUserOpWithEnable signs a user operation that installs the session key and executes in the same
+// user operation, using the enable signature produced by SignEnable.
+// The user operation nonce must use the enable mode NonceKey of the session key.
+func (k *SessionKey) SignUserOpWithEnable(ctx context.Context, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, enableSignature []byte) (string, error) {
+	userOpSig, err := k.signUserOp(ctx, op, entryPointVersion, chainID, true)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := k.EncodeEnableSignature(enableSignature, userOpSig)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(signature), nil
+}
+
+// EncodeEnableSignature encodes an enable mode user operation signature:
+// hook || abi.encode(validatorData, hookData, selectorData, enableSig, userOpSig).
+func (k *SessionKey) EncodeEnableSignature(enableSignature, userOpSignature []byte) ([]byte, error) {
+	encoded, err := enableSignatureArgs.Pack(k.enableData, []byte{}, executeSelector, enableSignature, userOpSignature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode enable signature: %w", err)
+	}
+	return append(common.Address{}.Bytes(), encoded...), nil
+}
+
+// signUserOp checks the nonce key and userOpHash of op and signs it with the session key.
+func (k *SessionKey) signUserOp(ctx context.Context, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, enable bool) ([]byte, error) {
+	opNonce, err := userop.ParseUint256(op.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	if key, _ := nonce.Decode(opNonce); key.Cmp(k.NonceKey(enable)) != 0 {
+		return nil, fmt.Errorf("user operation nonce key %#x does not select the session key (expected %#x)", key, k.NonceKey(enable))
+	}
+
+	userOpHash, err := userop.VerifyUserOpHash(op, entryPointVersion, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to sign user operation: %w", err)
+	}
+	return k.SignUserOpHash(ctx, userOpHash)
+}
+
+// derivePermissionID derives the permission ID of an ECDSA signer owned by owner as the ZeroDev SDK does:
+// keccak256(abi.encode(bytes[] policies, bytes signerData))[:4], with each policy as flag || module || data.
+func derivePermissionID(owner common.Address, policies []Policy) ([4]byte, error) {
+	var permissionID [4]byte
+	encoded, err := permissionIDArgs.Pack(policyEntries(policies), owner.Bytes())
+	if err != nil {
+		return permissionID, fmt.Errorf("failed to encode permission id data: %w", err)
+	}
+	copy(permissionID[:], crypto.Keccak256(encoded)[:4])
+	return permissionID, nil
+}
+
+// encodeEnableData encodes the permission validator install data for an ECDSA signer owned by owner.
+func encodeEnableData(owner common.Address, policies []Policy) ([]byte, error) {
+	entries := policyEntries(policies)
+	entries = append(entries, permissionEntry(PolicyFlagForAll, common.HexToAddress(constants.ECDSASignerAddress), owner.Bytes()))
+
+	encoded, err := enableDataArgs.Pack(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode permission enable data: %w", err)
+	}
+	return encoded, nil
+}
+
+// policyEntries packs the permission entries of policies.
+func policyEntries(policies []Policy) [][]byte {
+	entries := make([][]byte, 0, len(policies)+1)
+	for _, policy := range policies {
+		entries = append(entries, permissionEntry(policy.Flag, policy.Address, policy.Data))
+	}
+	return entries
+}
+
+// permissionEntry packs a policy or signer entry as flag || module || data.
+func permissionEntry(flag PolicyFlag, module common.Address, data []byte) []byte {
+	entry := make([]byte, 0, 2+common.AddressLength+len(data))
+	entry = append(entry, byte(flag>>8), byte(flag))
+	entry = append(entry, module.Bytes()...)
+	return append(entry, data...)
+}
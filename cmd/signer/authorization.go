@@ -0,0 +1,214 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// ErrInvalidAuthorizationSignature is returned when an authorization signature is malformed or not canonical.
+var ErrInvalidAuthorizationSignature = errors.New("invalid authorization signature")
+
+// ErrUniversalAuthorization is returned when signing an authorization with chain id 0 without opting in
+// through SignUniversalAuthorization or WithUniversalAuthorization.
+var ErrUniversalAuthorization = errors.New("authorization with chain id 0 is valid on every chain and requires explicit opt-in")
+
+type universalAuthorizationKey struct{}
+
+// WithUniversalAuthorization returns a context allowing Signer implementations to sign authorizations
+// with chain id 0. Such an authorization can be replayed on every chain where the EOA has the same nonce.
+func WithUniversalAuthorization(ctx context.Context) context.Context {
+	return context.WithValue(ctx, universalAuthorizationKey{}, true)
+}
+
+// universalAuthorizationAllowed reports whether ctx opts in to chain id 0 authorizations.
+func universalAuthorizationAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(universalAuthorizationKey{}).(bool)
+	return allowed
+}
+
+// checkAuthorizationChainID rejects chain id 0 authorizations unless ctx opts in to them.
+func checkAuthorizationChainID(ctx context.Context, auth types.Authorization) error {
+	if auth.ChainID == 0 && !universalAuthorizationAllowed(ctx) {
+		return ErrUniversalAuthorization
+	}
+	return nil
+}
+
+// UniversalAuthorizationWarning describes an authorization about to be signed with chain id 0.
+type UniversalAuthorizationWarning struct {
+	Authority common.Address // EOA signing the authorization
+	Delegate  string         // Contract the EOA delegates to
+	Nonce     uint64         // EOA nonce on the chains where the authorization is valid
+}
+
+// String returns a human-readable description of the warning.
+func (w UniversalAuthorizationWarning) String() string {
+	return fmt.Sprintf("signing EIP-7702 authorization valid on every chain: authority %s delegates to %s at nonce %d", w.Authority.Hex(), w.Delegate, w.Nonce)
+}
+
+// UniversalAuthorizationOption configures SignUniversalAuthorization.
+type UniversalAuthorizationOption func(*universalAuthorizationOptions)
+
+type universalAuthorizationOptions struct {
+	warningHandler func(ctx context.Context, warning UniversalAuthorizationWarning)
+}
+
+// WithWarningHandler sets a handler called before signing a universal authorization, e.g. to log it or
+// to ask the user for confirmation.
+func WithWarningHandler(handler func(ctx context.Context, warning UniversalAuthorizationWarning)) UniversalAuthorizationOption {
+	return func(o *universalAuthorizationOptions) {
+		o.warningHandler = handler
+	}
+}
+
+// SignUniversalAuthorization signs an EIP-7702 authorization with chain id 0, delegating the EOA to
+// delegateAddressHex on every chain where its nonce equals nonce. Anyone holding the authorization can
+// submit it on any such chain, so only delegate to contracts deployed at the same address everywhere.
+func SignUniversalAuthorization(ctx context.Context, s Signer, delegateAddressHex string, nonce uint64, opts ...UniversalAuthorizationOption) (*types.SignedAuthorization, error) {
+	var o universalAuthorizationOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.warningHandler != nil {
+		o.warningHandler(ctx, UniversalAuthorizationWarning{
+			Authority: s.Address(),
+			Delegate:  delegateAddressHex,
+			Nonce:     nonce,
+		})
+	}
+	return s.SignAuthorization(WithUniversalAuthorization(ctx), types.Authorization{
+		ChainID: 0,
+		Address: delegateAddressHex,
+		Nonce:   nonce,
+	})
+}
+
+// IsUniversalAuthorization reports whether auth is valid on every chain (chain id 0).
+func IsUniversalAuthorization(auth *types.SignedAuthorization) bool {
+	return auth.ChainID == 0
+}
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// RecoverAuthorizationSigner recovers the authority that signed an EIP-7702 authorization.
+// The signature must have a yParity of 0 or 1, consistent with V when V is set, and a low s value.
+func RecoverAuthorizationSigner(auth *types.SignedAuthorization) (common.Address, error) {
+	r, s, err := authorizationRS(auth)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if auth.YParity > 1 {
+		return common.Address{}, fmt.Errorf("%w: yParity must be 0 or 1, got %d", ErrInvalidAuthorizationSignature, auth.YParity)
+	}
+	if auth.V != "" {
+		v, err := strconv.ParseUint(auth.V, 0, 64)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("%w: invalid v %q", ErrInvalidAuthorizationSignature, auth.V)
+		}
+		if v >= 27 {
+			v -= 27
+		}
+		if v != uint64(auth.YParity) {
+			return common.Address{}, fmt.Errorf("%w: v %s does not match yParity %d", ErrInvalidAuthorizationSignature, auth.V, auth.YParity)
+		}
+	}
+	if r.Sign() == 0 || r.Cmp(secp256k1N) >= 0 {
+		return common.Address{}, fmt.Errorf("%w: r out of range", ErrInvalidAuthorizationSignature)
+	}
+	if s.Sign() == 0 || s.Cmp(secp256k1HalfN) > 0 {
+		return common.Address{}, fmt.Errorf("%w: s must be in the lower half of the curve order", ErrInvalidAuthorizationSignature)
+	}
+
+	hash, err := AuthorizationHash(types.Authorization{
+		ChainID: auth.ChainID,
+		Address: auth.Address,
+		Nonce:   auth.Nonce,
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	signature := make([]byte, 65)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:64])
+	signature[64] = auth.YParity
+	return recoverAddress(hash, signature)
+}
+
+// VerifyAuthorization verifies that an EIP-7702 authorization was signed by authority.
+// Returns true if the recovered authority matches the expected address.
+func VerifyAuthorization(auth *types.SignedAuthorization, authority string) (bool, error) {
+	if !common.IsHexAddress(authority) {
+		return false, fmt.Errorf("invalid authority address: %q", authority)
+	}
+	recovered, err := RecoverAuthorizationSigner(auth)
+	if err != nil {
+		return false, err
+	}
+	return recovered == common.HexToAddress(authority), nil
+}
+
+// ToSetCodeAuthorization converts a SignedAuthorization into go-ethereum's SetCodeAuthorization.
+func ToSetCodeAuthorization(auth *types.SignedAuthorization) (gethtypes.SetCodeAuthorization, error) {
+	if !common.IsHexAddress(auth.Address) {
+		return gethtypes.SetCodeAuthorization{}, fmt.Errorf("invalid authorization address: %q", auth.Address)
+	}
+	r, s, err := authorizationRS(auth)
+	if err != nil {
+		return gethtypes.SetCodeAuthorization{}, err
+	}
+
+	setCodeAuth := gethtypes.SetCodeAuthorization{
+		ChainID: *uint256.NewInt(auth.ChainID),
+		Address: common.HexToAddress(auth.Address),
+		Nonce:   auth.Nonce,
+		V:       auth.YParity,
+	}
+	if setCodeAuth.R.SetFromBig(r) || setCodeAuth.S.SetFromBig(s) {
+		return gethtypes.SetCodeAuthorization{}, fmt.Errorf("%w: r or s overflows 256 bits", ErrInvalidAuthorizationSignature)
+	}
+	return setCodeAuth, nil
+}
+
+// FromSetCodeAuthorization converts go-ethereum's SetCodeAuthorization into a SignedAuthorization.
+func FromSetCodeAuthorization(auth gethtypes.SetCodeAuthorization) (*types.SignedAuthorization, error) {
+	if !auth.ChainID.IsUint64() {
+		return nil, fmt.Errorf("authorization chain id %s does not fit in uint64", auth.ChainID.Dec())
+	}
+
+	return &types.SignedAuthorization{
+		ChainID: auth.ChainID.Uint64(),
+		Address: auth.Address.Hex(),
+		Nonce:   auth.Nonce,
+		R:       auth.R.Hex(),
+		S:       auth.S.Hex(),
+		V:       fmt.Sprintf("%d", auth.V),
+		YParity: auth.V,
+	}, nil
+}
+
+// authorizationRS parses the r and s signature components of an authorization.
+func authorizationRS(auth *types.SignedAuthorization) (*big.Int, *big.Int, error) {
+	r, err := userop.ParseUint256(auth.R)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid r: %v", ErrInvalidAuthorizationSignature, err)
+	}
+	s, err := userop.ParseUint256(auth.S)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid s: %v", ErrInvalidAuthorizationSignature, err)
+	}
+	return r, s, nil
+}
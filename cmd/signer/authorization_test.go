@@ -0,0 +1,50 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+func TestSignUniversalAuthorization(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	s := NewPrivateKeySigner(key)
+	delegate := "0xd6CEDDe84be40893d153Be9d467CD6aD37875b28"
+
+	// Signing chain id 0 directly, without opting in, fails.
+	_, err = s.SignAuthorization(ctx, types.Authorization{ChainID: 0, Address: delegate, Nonce: 3})
+	if !errors.Is(err, ErrUniversalAuthorization) {
+		t.Fatalf("SignAuthorization() error = %v, want %v", err, ErrUniversalAuthorization)
+	}
+
+	var warnings []UniversalAuthorizationWarning
+	auth, err := SignUniversalAuthorization(ctx, s, delegate, 3, WithWarningHandler(func(ctx context.Context, warning UniversalAuthorizationWarning) {
+		warnings = append(warnings, warning)
+	}))
+	if err != nil {
+		t.Fatalf("SignUniversalAuthorization() error = %v", err)
+	}
+	if !IsUniversalAuthorization(auth) {
+		t.Errorf("SignUniversalAuthorization() chain id = %d, want 0", auth.ChainID)
+	}
+	if ok, err := VerifyAuthorization(auth, s.Address().Hex()); err != nil || !ok {
+		t.Errorf("VerifyAuthorization() = %v, %v, want true", ok, err)
+	}
+
+	want := UniversalAuthorizationWarning{Authority: s.Address(), Delegate: delegate, Nonce: 3}
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("SignUniversalAuthorization() warnings = %v, want [%v]", warnings, want)
+	}
+
+	// Without a handler, signing works and nothing is reported.
+	if _, err := SignUniversalAuthorization(ctx, s, delegate, 3); err != nil {
+		t.Errorf("SignUniversalAuthorization() without handler error = %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// KeystoreSigner is a Signer backed by a go-ethereum encrypted keystore.
+// The key is decrypted only for the duration of each signature and never kept unlocked.
+type KeystoreSigner struct {
+	ks         *keystore.KeyStore
+	account    accounts.Account
+	passphrase string
+}
+
+var _ Signer = (*KeystoreSigner)(nil)
+
+// NewKeystoreSigner creates a Signer for the account with the given address in ks.
+func NewKeystoreSigner(ks *keystore.KeyStore, address common.Address, passphrase string) (*KeystoreSigner, error) {
+	account, err := ks.Find(accounts.Account{Address: address})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find keystore account %s: %w", address.Hex(), err)
+	}
+	return &KeystoreSigner{
+		ks:         ks,
+		account:    account,
+		passphrase: passphrase,
+	}, nil
+}
+
+// OpenKeystoreSigner opens the keystore directory keydir and creates a Signer for address.
+func OpenKeystoreSigner(keydir string, address common.Address, passphrase string) (*KeystoreSigner, error) {
+	ks := keystore.NewKeyStore(keydir, keystore.StandardScryptN, keystore.StandardScryptP)
+	return NewKeystoreSigner(ks, address, passphrase)
+}
+
+// Address returns the address of the keystore account.
+func (s *KeystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+// SignHash signs a 32-byte digest as is.
+func (s *KeystoreSigner) SignHash(_ context.Context, hash common.Hash) ([]byte, error) {
+	signature, err := s.ks.SignHashWithPassphrase(s.account, s.passphrase, hash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign hash: %w", err)
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+// SignMessage signs message using Ethereum's personal_sign format.
+func (s *KeystoreSigner) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	return s.SignHash(ctx, messageHash(message))
+}
+
+// SignTypedData signs EIP-712 typed data.
+func (s *KeystoreSigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignHash(ctx, hash)
+}
+
+// SignAuthorization signs an EIP-7702 authorization tuple.
+// Chain id 0 is rejected unless ctx comes from WithUniversalAuthorization.
+func (s *KeystoreSigner) SignAuthorization(ctx context.Context, auth types.Authorization) (*types.SignedAuthorization, error) {
+	if err := checkAuthorizationChainID(ctx, auth); err != nil {
+		return nil, err
+	}
+	hash, err := AuthorizationHash(auth)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.SignHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign authorization: %w", err)
+	}
+	return signedAuthorization(auth, signature)
+}
@@ -0,0 +1,86 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// PrivateKeySigner is a Signer holding an ECDSA private key in process memory.
+type PrivateKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+var _ Signer = (*PrivateKeySigner)(nil)
+
+// NewPrivateKeySigner creates a Signer for an in-memory private key.
+func NewPrivateKeySigner(privateKey *ecdsa.PrivateKey) *PrivateKeySigner {
+	return &PrivateKeySigner{
+		key:     privateKey,
+		address: crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+}
+
+// NewPrivateKeySignerFromHex creates a Signer from a hex-encoded private key, with or without 0x prefix.
+func NewPrivateKeySignerFromHex(privateKeyHex string) (*PrivateKeySigner, error) {
+	if len(privateKeyHex) >= 2 && privateKeyHex[:2] == "0x" {
+		privateKeyHex = privateKeyHex[2:]
+	}
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewPrivateKeySigner(privateKey), nil
+}
+
+// Address returns the address of the private key.
+func (s *PrivateKeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignHash signs a 32-byte digest as is.
+func (s *PrivateKeySigner) SignHash(_ context.Context, hash common.Hash) ([]byte, error) {
+	signature, err := crypto.Sign(hash.Bytes(), s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign hash: %w", err)
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+// SignMessage signs message using Ethereum's personal_sign format.
+func (s *PrivateKeySigner) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	return s.SignHash(ctx, messageHash(message))
+}
+
+// SignTypedData signs EIP-712 typed data.
+func (s *PrivateKeySigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignHash(ctx, hash)
+}
+
+// SignAuthorization signs an EIP-7702 authorization tuple.
+// Chain id 0 is rejected unless ctx comes from WithUniversalAuthorization.
+func (s *PrivateKeySigner) SignAuthorization(ctx context.Context, auth types.Authorization) (*types.SignedAuthorization, error) {
+	if err := checkAuthorizationChainID(ctx, auth); err != nil {
+		return nil, err
+	}
+	hash, err := AuthorizationHash(auth)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.SignHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign authorization: %w", err)
+	}
+	return signedAuthorization(auth, signature)
+}
@@ -0,0 +1,320 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// Remote signer protocol. All bodies are JSON; errors are returned as {"error": "..."} with a non-2xx status.
+//
+//	GET  /address             -> {"address": "0x..."}
+//	POST /sign/hash           {"hash": "0x..."}                         -> {"signature": "0x..."}
+//	POST /sign/message        {"message": "0x..."}                      -> {"signature": "0x..."}
+//	POST /sign/typed-data     {"typedData": {...}}                      -> {"signature": "0x..."}
+//	POST /sign/authorization  {"chainId": 1, "address": "0x...", "nonce": 0} -> SignedAuthorization
+//
+// Authorizations with chainId 0 additionally require "allowUniversal": true.
+const (
+	remotePathAddress       = "/address"
+	remotePathHash          = "/sign/hash"
+	remotePathMessage       = "/sign/message"
+	remotePathTypedData     = "/sign/typed-data"
+	remotePathAuthorization = "/sign/authorization"
+)
+
+type remoteAddressResponse struct {
+	Address common.Address `json:"address"`
+}
+
+type remoteHashRequest struct {
+	Hash common.Hash `json:"hash"`
+}
+
+type remoteMessageRequest struct {
+	Message hexutil.Bytes `json:"message"`
+}
+
+type remoteTypedDataRequest struct {
+	TypedData apitypes.TypedData `json:"typedData"`
+}
+
+type remoteAuthorizationRequest struct {
+	types.Authorization
+	AllowUniversal bool `json:"allowUniversal,omitempty"`
+}
+
+type remoteSignatureResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+type remoteErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// RemoteSigner is a Signer that delegates signing to a separate signing service over HTTP.
+// Every signature returned by the service is checked to recover to the service's address.
+type RemoteSigner struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    http.Header
+	address    common.Address
+}
+
+var _ Signer = (*RemoteSigner)(nil)
+
+// RemoteSignerOption configures optional RemoteSigner behavior.
+type RemoteSignerOption func(*RemoteSigner)
+
+// WithHTTPClient sets the HTTP client used to reach the signing service.
+func WithHTTPClient(httpClient *http.Client) RemoteSignerOption {
+	return func(s *RemoteSigner) {
+		s.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header sent with every request, e.g. for signing service authentication.
+func WithHeader(name string, value string) RemoteSignerOption {
+	return func(s *RemoteSigner) {
+		s.headers.Add(name, value)
+	}
+}
+
+// NewRemoteSigner connects to the signing service at baseURL and fetches its address.
+func NewRemoteSigner(ctx context.Context, baseURL string, opts ...RemoteSignerOption) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		headers: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	var resp remoteAddressResponse
+	if err := s.do(ctx, http.MethodGet, remotePathAddress, nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get remote signer address: %w", err)
+	}
+	s.address = resp.Address
+	return s, nil
+}
+
+// Address returns the address of the remote signing key.
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignHash asks the signing service to sign a 32-byte digest as is.
+func (s *RemoteSigner) SignHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	return s.sign(ctx, remotePathHash, remoteHashRequest{Hash: hash}, hash)
+}
+
+// SignMessage asks the signing service to sign message using Ethereum's personal_sign format.
+func (s *RemoteSigner) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	return s.sign(ctx, remotePathMessage, remoteMessageRequest{Message: message}, messageHash(message))
+}
+
+// SignTypedData asks the signing service to sign EIP-712 typed data.
+func (s *RemoteSigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	return s.sign(ctx, remotePathTypedData, remoteTypedDataRequest{TypedData: typedData}, hash)
+}
+
+// SignAuthorization asks the signing service to sign an EIP-7702 authorization tuple.
+// Chain id 0 is rejected unless ctx comes from WithUniversalAuthorization, in which case the opt-in is forwarded.
+func (s *RemoteSigner) SignAuthorization(ctx context.Context, auth types.Authorization) (*types.SignedAuthorization, error) {
+	if err := checkAuthorizationChainID(ctx, auth); err != nil {
+		return nil, err
+	}
+	hash, err := AuthorizationHash(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	req := remoteAuthorizationRequest{
+		Authorization:  auth,
+		AllowUniversal: auth.ChainID == 0,
+	}
+	var signed types.SignedAuthorization
+	if err := s.do(ctx, http.MethodPost, remotePathAuthorization, req, &signed); err != nil {
+		return nil, fmt.Errorf("failed to sign authorization: %w", err)
+	}
+	if signed.ChainID != auth.ChainID || signed.Nonce != auth.Nonce || !strings.EqualFold(signed.Address, auth.Address) {
+		return nil, fmt.Errorf("remote signer returned authorization for a different tuple")
+	}
+
+	rBytes, sBytes := common.FromHex(signed.R), common.FromHex(signed.S)
+	if len(rBytes) > 32 || len(sBytes) > 32 {
+		return nil, fmt.Errorf("remote signer returned invalid authorization signature")
+	}
+	signature := make([]byte, 65)
+	copy(signature[32-len(rBytes):32], rBytes)
+	copy(signature[64-len(sBytes):64], sBytes)
+	signature[64] = signed.YParity
+	if err := s.checkSigner(hash, signature); err != nil {
+		return nil, err
+	}
+	return &signed, nil
+}
+
+// sign posts req to path and checks that the returned signature over hash recovers to the signer address.
+func (s *RemoteSigner) sign(ctx context.Context, path string, req any, hash common.Hash) ([]byte, error) {
+	var resp remoteSignatureResponse
+	if err := s.do(ctx, http.MethodPost, path, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	if err := s.checkSigner(hash, resp.Signature); err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (s *RemoteSigner) checkSigner(hash common.Hash, signature []byte) error {
+	recovered, err := recoverAddress(hash, signature)
+	if err != nil {
+		return fmt.Errorf("remote signer returned invalid signature: %w", err)
+	}
+	if recovered != s.address {
+		return fmt.Errorf("remote signer returned signature from %s, expected %s", recovered.Hex(), s.address.Hex())
+	}
+	return nil
+}
+
+func (s *RemoteSigner) do(ctx context.Context, method string, path string, body any, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range s.headers {
+		for _, value := range values {
+			httpReq.Header.Add(name, value)
+		}
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp remoteErrorResponse
+		if json.Unmarshal(bodyBytes, &errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("remote signer returned status %d: %s", resp.StatusCode, errResp.Error)
+		}
+		return fmt.Errorf("remote signer returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if err := json.Unmarshal(bodyBytes, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// NewRemoteSignerHandler serves the remote signer protocol backed by s, e.g. as a local stand-in
+// for a signing service. It performs no authentication; wrap it to restrict access.
+func NewRemoteSignerHandler(s Signer) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET "+remotePathAddress, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, remoteAddressResponse{Address: s.Address()})
+	})
+	mux.HandleFunc("POST "+remotePathHash, func(w http.ResponseWriter, r *http.Request) {
+		var req remoteHashRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		writeSignature(w, func() ([]byte, error) { return s.SignHash(r.Context(), req.Hash) })
+	})
+	mux.HandleFunc("POST "+remotePathMessage, func(w http.ResponseWriter, r *http.Request) {
+		var req remoteMessageRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		writeSignature(w, func() ([]byte, error) { return s.SignMessage(r.Context(), req.Message) })
+	})
+	mux.HandleFunc("POST "+remotePathTypedData, func(w http.ResponseWriter, r *http.Request) {
+		var req remoteTypedDataRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		writeSignature(w, func() ([]byte, error) { return s.SignTypedData(r.Context(), req.TypedData) })
+	})
+	mux.HandleFunc("POST "+remotePathAuthorization, func(w http.ResponseWriter, r *http.Request) {
+		var req remoteAuthorizationRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		ctx := r.Context()
+		if req.AllowUniversal {
+			ctx = WithUniversalAuthorization(ctx)
+		}
+		signed, err := s.SignAuthorization(ctx, req.Authorization)
+		if err != nil {
+			if errors.Is(err, ErrUniversalAuthorization) {
+				writeJSON(w, http.StatusBadRequest, remoteErrorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, remoteErrorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, signed)
+	})
+
+	return mux
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, remoteErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return false
+	}
+	return true
+}
+
+func writeSignature(w http.ResponseWriter, sign func() ([]byte, error)) {
+	signature, err := sign()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, remoteErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, remoteSignatureResponse{Signature: signature})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
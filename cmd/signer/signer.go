@@ -1,6 +1,8 @@
+// Package signer signs user operations, messages and EIP-7702 authorizations with pluggable key backends.
 package signer
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
@@ -8,37 +10,62 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
 	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
 )
 
+// Signer signs on behalf of an EOA without exposing its private key.
+// Signatures are 65 bytes in R || S || V format with V being 27 or 28.
+type Signer interface {
+	// Address returns the address of the signing EOA.
+	Address() common.Address
+	// SignHash signs a 32-byte digest as is.
+	SignHash(ctx context.Context, hash common.Hash) ([]byte, error)
+	// SignMessage signs message using Ethereum's personal_sign (EIP-191) format.
+	SignMessage(ctx context.Context, message []byte) ([]byte, error)
+	// SignTypedData signs EIP-712 typed data.
+	SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error)
+	// SignAuthorization signs an EIP-7702 authorization tuple.
+	SignAuthorization(ctx context.Context, auth types.Authorization) (*types.SignedAuthorization, error)
+}
+
 // SignUserOpHash signs a user operation hash using Ethereum's personal_sign format.
 // Returns signature as hex string (0x-prefixed) in R || S || V format.
 func SignUserOpHash(userOpHash string, privateKey *ecdsa.PrivateKey) (string, error) {
+	return SignUserOpHashWithSigner(context.Background(), userOpHash, NewPrivateKeySigner(privateKey))
+}
+
+// SignUserOpHashWithSigner signs a user operation hash with s using Ethereum's personal_sign format.
+// Returns signature as hex string (0x-prefixed) in R || S || V format.
+func SignUserOpHashWithSigner(ctx context.Context, userOpHash string, s Signer) (string, error) {
 	hashBytes := common.FromHex(userOpHash)
 	if len(hashBytes) != 32 {
 		return "", fmt.Errorf("invalid hash length: expected 32 bytes, got %d", len(hashBytes))
 	}
 
-	prefixedHash := accounts.TextHash(hashBytes)
-
-	signatureBytes, err := crypto.Sign(prefixedHash, privateKey)
+	signature, err := s.SignMessage(ctx, hashBytes)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign hash: %w", err)
 	}
 
-	v := signatureBytes[64]
-	if v < 27 {
-		v += 27
-	}
-	r := signatureBytes[:32]
-	s := signatureBytes[32:64]
+	return hexutil.Encode(signature), nil
+}
 
-	useropSignature := append(r, s...)
-	useropSignature = append(useropSignature, v)
+// SignUserOp recomputes the userOpHash of a builder response locally and signs it with s.
+// Refuses to sign when the computed hash does not match the UserOpHash returned by the builder.
+// opts are passed to userop.VerifyUserOpHash, e.g. userop.WithDelegate for an already delegated EIP-7702 account.
+func SignUserOp(ctx context.Context, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, s Signer, opts ...userop.HashOption) (string, error) {
+	userOpHash, err := userop.VerifyUserOpHash(op, entryPointVersion, chainID, opts...)
+	if err != nil {
+		return "", fmt.Errorf("refusing to sign user operation: %w", err)
+	}
 
-	return "0x" + hex.EncodeToString(useropSignature), nil
+	return SignUserOpHashWithSigner(ctx, userOpHash.Hex(), s)
 }
 
 // VerifyUserOpSignature verifies that a signature is valid for a given user operation hash.
@@ -73,29 +100,43 @@ func VerifyUserOpSignature(userOpHash, signature, address string) (bool, error)
 
 // SignAuthorization signs an EIP-7702 authorization tuple.
 // EIP-7702 allows EOAs to delegate execution to a contract implementation.
-// Returns a SignedAuthorization with signature components.
+// Returns a SignedAuthorization with signature components. Chain id 0 is rejected, use SignUniversalAuthorization.
 func SignAuthorization(chainID uint64, delegateAddressHex string, nonce uint64, privateKey *ecdsa.PrivateKey) (*types.SignedAuthorization, error) {
-	addr := common.HexToAddress(delegateAddressHex)
+	return NewPrivateKeySigner(privateKey).SignAuthorization(context.Background(), types.Authorization{
+		ChainID: chainID,
+		Address: delegateAddressHex,
+		Nonce:   nonce,
+	})
+}
+
+// AuthorizationHash returns the digest signed for an EIP-7702 authorization tuple:
+// keccak256(0x05 || rlp([chainId, address, nonce])).
+func AuthorizationHash(auth types.Authorization) (common.Hash, error) {
+	if !common.IsHexAddress(auth.Address) {
+		return common.Hash{}, fmt.Errorf("invalid authorization address: %q", auth.Address)
+	}
 
 	authTuple := []interface{}{
-		chainID,
-		addr,
-		nonce,
+		auth.ChainID,
+		common.HexToAddress(auth.Address),
+		auth.Nonce,
 	}
 
 	rlpEncoded, err := rlp.EncodeToBytes(authTuple)
 	if err != nil {
-		return nil, fmt.Errorf("failed to RLP encode authorization tuple: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to RLP encode authorization tuple: %w", err)
 	}
 
 	magic := byte(0x05)
 	authMessage := append([]byte{magic}, rlpEncoded...)
 
-	authHash := crypto.Keccak256Hash(authMessage)
+	return crypto.Keccak256Hash(authMessage), nil
+}
 
-	signature, err := crypto.Sign(authHash.Bytes(), privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign authorization: %w", err)
+// signedAuthorization builds a SignedAuthorization from a 65-byte signature over the authorization hash.
+func signedAuthorization(auth types.Authorization, signature []byte) (*types.SignedAuthorization, error) {
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("invalid signature length: expected 65 bytes, got %d", len(signature))
 	}
 
 	r := new(big.Int).SetBytes(signature[:32])
@@ -108,12 +149,44 @@ func SignAuthorization(chainID uint64, delegateAddressHex string, nonce uint64,
 	}
 
 	return &types.SignedAuthorization{
-		ChainID: chainID,
-		Address: delegateAddressHex,
-		Nonce:   nonce,
+		ChainID: auth.ChainID,
+		Address: auth.Address,
+		Nonce:   auth.Nonce,
 		R:       "0x" + hex.EncodeToString(r.Bytes()),
 		S:       "0x" + hex.EncodeToString(s.Bytes()),
-		V:       fmt.Sprintf("%d", v),
+		V:       fmt.Sprintf("%d", yParity),
 		YParity: uint8(yParity),
 	}, nil
 }
+
+// recoverAddress returns the address that produced a 65-byte R || S || V signature over hash.
+func recoverAddress(hash common.Hash, signature []byte) (common.Address, error) {
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length: expected 65 bytes, got %d", len(signature))
+	}
+	sig := make([]byte, 65)
+	copy(sig, signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover public key: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// typedDataHash returns the EIP-712 digest of typedData.
+func typedDataHash(typedData apitypes.TypedData) (common.Hash, error) {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// messageHash returns the EIP-191 personal_sign digest of message.
+func messageHash(message []byte) common.Hash {
+	return common.BytesToHash(accounts.TextHash(message))
+}
@@ -0,0 +1,236 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// ERC6492MagicSuffix is appended to ERC-6492 wrapped signatures of counterfactual accounts.
+var ERC6492MagicSuffix = common.FromHex("0x6492649264926492649264926492649264926492649264926492649264926492")
+
+// ERC1271MagicValue is returned by isValidSignature for a valid signature.
+var ERC1271MagicValue = [4]byte{0x16, 0x26, 0xba, 0x7e}
+
+// delegationPrefix marks the code of an EOA delegated with EIP-7702.
+var delegationPrefix = []byte{0xef, 0x01, 0x00}
+
+var (
+	verifyAddressType, _ = abi.NewType("address", "", nil)
+	verifyBytes32Type, _ = abi.NewType("bytes32", "", nil)
+	verifyBytesType, _   = abi.NewType("bytes", "", nil)
+	erc6492Args          = abi.Arguments{{Type: verifyAddressType}, {Type: verifyBytesType}, {Type: verifyBytesType}}
+	isValidSignatureArgs = abi.Arguments{{Type: verifyBytes32Type}, {Type: verifyBytesType}}
+	isValidSignatureSel  = crypto.Keccak256([]byte("isValidSignature(bytes32,bytes)"))[:4]
+)
+
+// Verifier verifies signatures from EOAs, deployed ERC-1271 contract accounts and
+// counterfactual accounts using ERC-6492 wrapped signatures.
+type Verifier struct {
+	caller bind.ContractCaller
+}
+
+// NewVerifier creates a Verifier reading chain state through caller, e.g. an *ethclient.Client
+// or go-ethereum's simulated backend client.
+func NewVerifier(caller bind.ContractCaller) *Verifier {
+	return &Verifier{caller: caller}
+}
+
+// VerifyHash reports whether signature is a valid signature of hash by address.
+//
+// ERC-6492 wrapped signatures are unwrapped; when the account is not deployed yet, its deployment
+// is simulated in the same eth_call as isValidSignature. Deployed contracts are checked with
+// ERC-1271 isValidSignature, and EOAs with ecrecover. EOAs delegated with EIP-7702 are accepted
+// by either method.
+func (v *Verifier) VerifyHash(ctx context.Context, address common.Address, hash common.Hash, signature []byte) (bool, error) {
+	factory, factoryData, innerSig, wrapped, err := UnwrapERC6492Signature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	code, err := v.caller.CodeAt(ctx, address, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get code at %s: %w", address.Hex(), err)
+	}
+
+	if len(code) == 0 {
+		if wrapped {
+			return v.verifyCounterfactual(ctx, address, hash, innerSig, factory, factoryData)
+		}
+		return ecrecoverMatches(address, hash, innerSig), nil
+	}
+
+	valid, err := v.isValidSignature(ctx, address, hash, innerSig)
+	if err != nil {
+		return false, err
+	}
+	if !valid && bytes.HasPrefix(code, delegationPrefix) {
+		return ecrecoverMatches(address, hash, innerSig), nil
+	}
+	return valid, nil
+}
+
+// VerifyMessage reports whether signature is a valid personal_sign (EIP-191) signature of message by address.
+func (v *Verifier) VerifyMessage(ctx context.Context, address common.Address, message []byte, signature []byte) (bool, error) {
+	return v.VerifyHash(ctx, address, messageHash(message), signature)
+}
+
+// VerifyTypedData reports whether signature is a valid EIP-712 signature of typedData by address.
+func (v *Verifier) VerifyTypedData(ctx context.Context, address common.Address, typedData apitypes.TypedData, signature []byte) (bool, error) {
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		return false, err
+	}
+	return v.VerifyHash(ctx, address, hash, signature)
+}
+
+// UnwrapERC6492Signature splits an ERC-6492 wrapped signature into the factory, factory calldata
+// and inner signature. Signatures without the ERC-6492 suffix are returned as is with wrapped set to false.
+func UnwrapERC6492Signature(signature []byte) (factory common.Address, factoryData []byte, innerSig []byte, wrapped bool, err error) {
+	if !bytes.HasSuffix(signature, ERC6492MagicSuffix) {
+		return common.Address{}, nil, signature, false, nil
+	}
+
+	values, err := erc6492Args.Unpack(signature[:len(signature)-len(ERC6492MagicSuffix)])
+	if err != nil {
+		return common.Address{}, nil, nil, false, fmt.Errorf("failed to decode ERC-6492 signature: %w", err)
+	}
+	return values[0].(common.Address), values[1].([]byte), values[2].([]byte), true, nil
+}
+
+// WrapERC6492Signature wraps signature for a counterfactual account deployed by calling factory with factoryData.
+func WrapERC6492Signature(factory common.Address, factoryData []byte, signature []byte) ([]byte, error) {
+	encoded, err := erc6492Args.Pack(factory, factoryData, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ERC-6492 signature: %w", err)
+	}
+	return append(encoded, ERC6492MagicSuffix...), nil
+}
+
+// isValidSignature calls ERC-1271 isValidSignature on a deployed contract.
+// A reverting call or a missing function counts as an invalid signature.
+func (v *Verifier) isValidSignature(ctx context.Context, address common.Address, hash common.Hash, signature []byte) (bool, error) {
+	data, err := encodeIsValidSignature(hash, signature)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := v.caller.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, nil)
+	if err != nil {
+		if isRevert(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to call isValidSignature: %w", err)
+	}
+	return len(result) >= 4 && [4]byte(result[:4]) == ERC1271MagicValue, nil
+}
+
+// verifyCounterfactual deploys the account and calls isValidSignature within a single eth_call,
+// using a contract creation whose constructor performs both calls and returns the result.
+func (v *Verifier) verifyCounterfactual(ctx context.Context, address common.Address, hash common.Hash, signature []byte, factory common.Address, factoryData []byte) (bool, error) {
+	validateData, err := encodeIsValidSignature(hash, signature)
+	if err != nil {
+		return false, err
+	}
+	initCode, err := counterfactualValidatorCode(factory, factoryData, address, validateData)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := v.caller.CallContract(ctx, ethereum.CallMsg{Data: initCode}, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to simulate account deployment: %w", err)
+	}
+	if len(result) != 64 {
+		return false, fmt.Errorf("unexpected deployless validation result length: %d", len(result))
+	}
+
+	success := new(big.Int).SetBytes(result[32:64]).Sign() != 0
+	return success && [4]byte(result[:4]) == ERC1271MagicValue, nil
+}
+
+func encodeIsValidSignature(hash common.Hash, signature []byte) ([]byte, error) {
+	encoded, err := isValidSignatureArgs.Pack(hash, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode isValidSignature call: %w", err)
+	}
+	return append(append([]byte{}, isValidSignatureSel...), encoded...), nil
+}
+
+// counterfactualValidatorCode returns contract creation code that calls factory with factoryData,
+// then staticcalls account with validateData and returns (returndata[0:32], success) as 64 bytes.
+// Both calldata blobs are appended to the code and copied to memory by the constructor.
+func counterfactualValidatorCode(factory common.Address, factoryData []byte, account common.Address, validateData []byte) ([]byte, error) {
+	payloadLen := len(factoryData) + len(validateData)
+	if payloadLen+64 > 0xffff {
+		return nil, fmt.Errorf("ERC-6492 payload too large: %d bytes", payloadLen)
+	}
+
+	build := func(codeLen int) []byte {
+		var code []byte
+		push1 := func(v byte) { code = append(code, 0x60, v) }
+		push2 := func(v int) { code = append(code, 0x61, byte(v>>8), byte(v)) }
+		push20 := func(a common.Address) { code = append(append(code, 0x73), a.Bytes()...) }
+
+		// CODECOPY(0, codeLen, payloadLen)
+		push2(payloadLen)
+		push2(codeLen)
+		push1(0)
+		code = append(code, 0x39)
+
+		// POP(CALL(gas, factory, 0, 0, len(factoryData), 0, 0))
+		push1(0)
+		push1(0)
+		push2(len(factoryData))
+		push1(0)
+		push1(0)
+		push20(factory)
+		code = append(code, 0x5a, 0xf1, 0x50)
+
+		// MSTORE(payloadLen+32, STATICCALL(gas, account, len(factoryData), len(validateData), payloadLen, 32))
+		push1(0x20)
+		push2(payloadLen)
+		push2(len(validateData))
+		push2(len(factoryData))
+		push20(account)
+		code = append(code, 0x5a, 0xfa)
+		push2(payloadLen + 32)
+		code = append(code, 0x52)
+
+		// RETURN(payloadLen, 64)
+		push1(0x40)
+		push2(payloadLen)
+		code = append(code, 0xf3)
+		return code
+	}
+
+	code := build(0)
+	code = build(len(code))
+	code = append(code, factoryData...)
+	return append(code, validateData...), nil
+}
+
+// isRevert reports whether err is an execution revert rather than a transport failure.
+func isRevert(err error) bool {
+	var dataErr interface{ ErrorData() interface{} }
+	if errors.As(err, &dataErr) {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+// ecrecoverMatches reports whether a 65-byte signature of hash recovers to address.
+func ecrecoverMatches(address common.Address, hash common.Hash, signature []byte) bool {
+	recovered, err := recoverAddress(hash, signature)
+	return err == nil && recovered == address
+}
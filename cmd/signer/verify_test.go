@@ -0,0 +1,133 @@
+package signer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+// accountRuntime returns the runtime code of a minimal ERC-1271 account whose isValidSignature
+// returns the magic value when the 65-byte signature recovers to owner, and zero otherwise.
+func accountRuntime(owner common.Address) []byte {
+	code := common.FromHex(
+		"600435600052" + // mstore(0x00, hash)
+			"60a43560f81c602052" + // mstore(0x20, v)
+			"606435604052" + // mstore(0x40, r)
+			"608435606052" + // mstore(0x60, s)
+			"602060806080600060015afa50", // pop(staticcall(gas, ecrecover, 0x00, 0x80, 0x80, 0x20))
+	)
+	code = append(code, 0x60, 0x80, 0x51, 0x73) // mload(0x80) == owner
+	code = append(code, owner.Bytes()...)
+	code = append(code, 0x14)
+	jumpDest := len(code) + 3 + 5
+	code = append(code, 0x60, byte(jumpDest), 0x57)      // jumpi(valid)
+	code = append(code, common.FromHex("602060a0f3")...) // return(0xa0, 0x20), zero
+	return append(code, common.FromHex("5b631626ba7e60e01b60005260206000f3")...)
+}
+
+// initCode returns contract creation code deploying runtime.
+func initCode(runtime []byte) []byte {
+	code := []byte{0x60, byte(len(runtime)), 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, byte(len(runtime)), 0x60, 0x00, 0xf3}
+	return append(code, runtime...)
+}
+
+// factoryRuntime returns the runtime code of a factory deploying accountInit with CREATE2 and salt 0
+// on any call, and returning the deployed address.
+func factoryRuntime(accountInit []byte) []byte {
+	size := byte(len(accountInit))
+	code := []byte{
+		0x60, size, 0x60, 24, 0x60, 0x00, 0x39, // codecopy(0, 24, size)
+		0x60, 0x00, 0x60, size, 0x60, 0x00, 0x60, 0x00, 0xf5, // create2(0, 0, size, 0)
+		0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3, // mstore(0, address), return(0, 32)
+	}
+	return append(code, accountInit...)
+}
+
+func TestVerifierVerifyHash(t *testing.T) {
+	ownerKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	owner := NewPrivateKeySigner(ownerKey)
+	other := NewPrivateKeySigner(otherKey)
+
+	deployed := common.HexToAddress("0x1271000000000000000000000000000000001271")
+	factory := common.HexToAddress("0x6492000000000000000000000000000000006492")
+	accountInit := initCode(accountRuntime(owner.Address()))
+	counterfactual := crypto.CreateAddress2(factory, [32]byte{}, crypto.Keccak256(accountInit))
+
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		deployed: {Code: accountRuntime(owner.Address()), Balance: common.Big0},
+		factory:  {Code: factoryRuntime(accountInit), Balance: common.Big0},
+	})
+	defer backend.Close()
+	verifier := NewVerifier(backend.Client())
+
+	ctx := context.Background()
+	hash := crypto.Keccak256Hash([]byte("hello"))
+	ownerSig, err := owner.SignHash(ctx, hash)
+	if err != nil {
+		t.Fatalf("SignHash() error = %v", err)
+	}
+	otherSig, err := other.SignHash(ctx, hash)
+	if err != nil {
+		t.Fatalf("SignHash() error = %v", err)
+	}
+	wrap := func(sig []byte) []byte {
+		wrapped, err := WrapERC6492Signature(factory, []byte{0x01}, sig)
+		if err != nil {
+			t.Fatalf("WrapERC6492Signature() error = %v", err)
+		}
+		return wrapped
+	}
+
+	tests := []struct {
+		name      string
+		address   common.Address
+		signature []byte
+		want      bool
+	}{
+		{"EOA valid", owner.Address(), ownerSig, true},
+		{"EOA invalid", owner.Address(), otherSig, false},
+		{"ERC-1271 valid", deployed, ownerSig, true},
+		{"ERC-1271 invalid", deployed, otherSig, false},
+		{"ERC-1271 wrapped valid", deployed, wrap(ownerSig), true},
+		{"ERC-6492 valid", counterfactual, wrap(ownerSig), true},
+		{"ERC-6492 invalid", counterfactual, wrap(otherSig), false},
+		{"ERC-6492 unwrapped", counterfactual, ownerSig, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.VerifyHash(ctx, tt.address, hash, tt.signature)
+			if err != nil {
+				t.Fatalf("VerifyHash() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestERC6492SignatureRoundTrip(t *testing.T) {
+	factory := common.HexToAddress("0x6492000000000000000000000000000000006492")
+	wrapped, err := WrapERC6492Signature(factory, []byte{0xde, 0xad}, []byte{0xbe, 0xef})
+	if err != nil {
+		t.Fatalf("WrapERC6492Signature() error = %v", err)
+	}
+
+	gotFactory, gotData, gotSig, ok, err := UnwrapERC6492Signature(wrapped)
+	if err != nil {
+		t.Fatalf("UnwrapERC6492Signature() error = %v", err)
+	}
+	if !ok || gotFactory != factory || common.Bytes2Hex(gotData) != "dead" || common.Bytes2Hex(gotSig) != "beef" {
+		t.Errorf("UnwrapERC6492Signature() = %s, %x, %x, %v", gotFactory.Hex(), gotData, gotSig, ok)
+	}
+
+	if _, _, sig, ok, err := UnwrapERC6492Signature([]byte{0xbe, 0xef}); err != nil || ok || common.Bytes2Hex(sig) != "beef" {
+		t.Errorf("UnwrapERC6492Signature() on a plain signature = %x, %v, %v", sig, ok, err)
+	}
+}
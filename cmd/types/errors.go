@@ -0,0 +1,10 @@
+package types
+
+import "errors"
+
+// Sentinel errors shared by every user operation backend (UserOp builder, bundler JSON-RPC).
+// Use errors.Is to match them.
+var (
+	ErrValidationFailed = errors.New("user operation validation failed")
+	ErrReceiptNotFound  = errors.New("receipt not found yet")
+)
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// flexInt decodes integers given either as JSON numbers or as hex/decimal strings,
+// since bundler JSON-RPC responses encode log and transaction indexes as hex quantities.
+type flexInt int
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *flexInt) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*i = flexInt(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid integer: %s", data)
+	}
+	n, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer: %q", s)
+	}
+	*i = flexInt(n)
+	return nil
+}
+
+// UnmarshalJSON accepts LogIndex and TransactionIndex as numbers or hex strings.
+func (l *Log) UnmarshalJSON(data []byte) error {
+	type plain Log
+	aux := struct {
+		*plain
+		LogIndex         flexInt `json:"logIndex"`
+		TransactionIndex flexInt `json:"transactionIndex"`
+	}{plain: (*plain)(l)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	l.LogIndex = int(aux.LogIndex)
+	l.TransactionIndex = int(aux.TransactionIndex)
+	return nil
+}
+
+// UnmarshalJSON accepts TransactionIndex as a number or hex string.
+func (r *TransactionReceipt) UnmarshalJSON(data []byte) error {
+	type plain TransactionReceipt
+	aux := struct {
+		*plain
+		TransactionIndex flexInt `json:"transactionIndex"`
+	}{plain: (*plain)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.TransactionIndex = int(aux.TransactionIndex)
+	return nil
+}
@@ -0,0 +1,210 @@
+package userop
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// ErrUserOpHashMismatch is returned when a locally computed userOpHash differs from the builder's value.
+var ErrUserOpHashMismatch = errors.New("userOpHash mismatch")
+
+var (
+	addressType, _ = abi.NewType("address", "", nil)
+	uint256Type, _ = abi.NewType("uint256", "", nil)
+	bytes32Type, _ = abi.NewType("bytes32", "", nil)
+
+	// packedUserOpTypeHash is the EIP-712 type hash of PackedUserOperation used by EntryPoint v0.8.
+	packedUserOpTypeHash = crypto.Keccak256Hash([]byte("PackedUserOperation(address sender,uint256 nonce,bytes initCode,bytes callData,bytes32 accountGasLimits,uint256 preVerificationGas,bytes32 gasFees,bytes paymasterAndData)"))
+
+	// eip712DomainTypeHash is the EIP-712 domain type hash including the verifying contract.
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+)
+
+// HashOption configures GetUserOpHash and VerifyUserOpHash.
+type HashOption func(*hashOptions)
+
+type hashOptions struct {
+	delegate *common.Address
+}
+
+// WithDelegate sets the current EIP-7702 delegate of the sender, to hash EntryPoint v0.8 user operations
+// of an EOA that is already delegated and carries no authorization. The delegate of an authorization
+// in the user operation takes precedence, since it is the one set when the user operation is included.
+func WithDelegate(delegate common.Address) HashOption {
+	return func(o *hashOptions) {
+		o.delegate = &delegate
+	}
+}
+
+// GetUserOpHash computes the userOpHash of a builder response for the given EntryPoint version and chain.
+// EntryPoint v0.8 user operations carrying the EIP-7702 marker need the sender's delegate, taken from
+// op.Authorization or, without one, from WithDelegate.
+func GetUserOpHash(op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, opts ...HashOption) (common.Hash, error) {
+	packed, err := Pack(op, entryPointVersion)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to pack user operation: %w", err)
+	}
+
+	var o hashOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	delegate := o.delegate
+	if op.Authorization != nil && common.IsHexAddress(op.Authorization.Address) {
+		addr := common.HexToAddress(op.Authorization.Address)
+		delegate = &addr
+	}
+
+	return GetPackedUserOpHash(packed, entryPointVersion, chainID, delegate)
+}
+
+// GetPackedUserOpHash computes the userOpHash of a packed user operation.
+// The delegate is the EIP-7702 implementation of the sender and is only consulted for
+// EntryPoint v0.8 user operations whose init code carries the EIP-7702 marker.
+func GetPackedUserOpHash(op *PackedUserOperation, entryPointVersion constants.EntryPointVersion, chainID uint64, delegate *common.Address) (common.Hash, error) {
+	entryPointHex, err := constants.GetEntryPointAddress(entryPointVersion)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	entryPoint := common.HexToAddress(entryPointHex)
+
+	switch entryPointVersion {
+	case constants.EntryPointVersion06:
+		return hashV06(op, entryPoint, chainID)
+	case constants.EntryPointVersion07:
+		return hashV07(op, entryPoint, chainID)
+	case constants.EntryPointVersion08:
+		return hashV08(op, entryPoint, chainID, delegate)
+	default:
+		return common.Hash{}, fmt.Errorf("unsupported entrypoint version: %s", entryPointVersion)
+	}
+}
+
+// VerifyUserOpHash recomputes the userOpHash of a builder response and compares it with op.UserOpHash.
+// Returns an error wrapping ErrUserOpHashMismatch when the hashes differ.
+func VerifyUserOpHash(op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, opts ...HashOption) (common.Hash, error) {
+	computed, err := GetUserOpHash(op, entryPointVersion, chainID, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	received := common.FromHex(op.UserOpHash)
+	if len(received) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid userOpHash length: expected 32 bytes, got %d", len(received))
+	}
+	if common.BytesToHash(received) != computed {
+		return common.Hash{}, fmt.Errorf("%w: builder returned %s, computed %s", ErrUserOpHashMismatch, op.UserOpHash, computed.Hex())
+	}
+
+	return computed, nil
+}
+
+// hashV06 computes the EntryPoint v0.6 userOpHash from the unpacked gas fields.
+func hashV06(op *PackedUserOperation, entryPoint common.Address, chainID uint64) (common.Hash, error) {
+	args := abi.Arguments{
+		{Type: addressType}, {Type: uint256Type}, {Type: bytes32Type}, {Type: bytes32Type},
+		{Type: uint256Type}, {Type: uint256Type}, {Type: uint256Type}, {Type: uint256Type},
+		{Type: uint256Type}, {Type: bytes32Type},
+	}
+	encoded, err := args.Pack(
+		op.Sender,
+		op.Nonce,
+		crypto.Keccak256Hash(op.InitCode),
+		crypto.Keccak256Hash(op.CallData),
+		op.CallGasLimit(),
+		op.VerificationGasLimit(),
+		op.PreVerificationGas,
+		op.MaxFeePerGas(),
+		op.MaxPriorityFeePerGas(),
+		crypto.Keccak256Hash(op.PaymasterAndData),
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode user operation: %w", err)
+	}
+
+	return wrapWithEntryPoint(crypto.Keccak256Hash(encoded), entryPoint, chainID)
+}
+
+// hashV07 computes the EntryPoint v0.7 userOpHash.
+func hashV07(op *PackedUserOperation, entryPoint common.Address, chainID uint64) (common.Hash, error) {
+	encoded, err := encodePacked(op, crypto.Keccak256Hash(op.InitCode))
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return wrapWithEntryPoint(crypto.Keccak256Hash(encoded), entryPoint, chainID)
+}
+
+// hashV08 computes the EntryPoint v0.8 userOpHash as an EIP-712 digest.
+func hashV08(op *PackedUserOperation, entryPoint common.Address, chainID uint64, delegate *common.Address) (common.Hash, error) {
+	initCodeHash := crypto.Keccak256Hash(op.InitCode)
+	if op.IsEip7702InitCode() {
+		if delegate == nil {
+			return common.Hash{}, fmt.Errorf("EIP-7702 init code requires the sender's delegate address, from an authorization or WithDelegate")
+		}
+		if len(op.InitCode) <= common.AddressLength {
+			initCodeHash = crypto.Keccak256Hash(delegate.Bytes())
+		} else {
+			initCodeHash = crypto.Keccak256Hash(delegate.Bytes(), op.InitCode[common.AddressLength:])
+		}
+	}
+
+	encoded, err := encodePacked(op, initCodeHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	structHash := crypto.Keccak256Hash(append(packedUserOpTypeHash.Bytes(), encoded...))
+
+	domainArgs := abi.Arguments{{Type: bytes32Type}, {Type: bytes32Type}, {Type: bytes32Type}, {Type: uint256Type}, {Type: addressType}}
+	domain, err := domainArgs.Pack(
+		eip712DomainTypeHash,
+		crypto.Keccak256Hash([]byte("ERC4337")),
+		crypto.Keccak256Hash([]byte("1")),
+		new(big.Int).SetUint64(chainID),
+		entryPoint,
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode domain: %w", err)
+	}
+	domainSeparator := crypto.Keccak256Hash(domain)
+
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), structHash.Bytes()), nil
+}
+
+// encodePacked ABI-encodes the hashed fields of a PackedUserOperation shared by v0.7 and v0.8.
+func encodePacked(op *PackedUserOperation, initCodeHash common.Hash) ([]byte, error) {
+	args := abi.Arguments{
+		{Type: addressType}, {Type: uint256Type}, {Type: bytes32Type}, {Type: bytes32Type},
+		{Type: bytes32Type}, {Type: uint256Type}, {Type: bytes32Type}, {Type: bytes32Type},
+	}
+	encoded, err := args.Pack(
+		op.Sender,
+		op.Nonce,
+		initCodeHash,
+		crypto.Keccak256Hash(op.CallData),
+		op.AccountGasLimits,
+		op.PreVerificationGas,
+		op.GasFees,
+		crypto.Keccak256Hash(op.PaymasterAndData),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user operation: %w", err)
+	}
+	return encoded, nil
+}
+
+func wrapWithEntryPoint(opHash common.Hash, entryPoint common.Address, chainID uint64) (common.Hash, error) {
+	args := abi.Arguments{{Type: bytes32Type}, {Type: addressType}, {Type: uint256Type}}
+	encoded, err := args.Pack(opHash, entryPoint, new(big.Int).SetUint64(chainID))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode userOpHash: %w", err)
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
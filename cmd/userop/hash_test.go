@@ -0,0 +1,168 @@
+package userop
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+const (
+	testSender   = "0x1111111111111111111111111111111111111111"
+	testCallData = "0xb61d27f60000000000000000000000002222222222222222222222222222222222222222"
+	testDelegate = "0xd6CEDDe84be40893d153Be9d467CD6aD37875b28"
+)
+
+// testPackedOp returns a v0.7/v0.8 user operation with a factory and a paymaster, in unpacked form.
+func testPackedOp() *types.BuildUserOpResponse {
+	return &types.BuildUserOpResponse{
+		Sender:                        testSender,
+		Nonce:                         "0x12340000000000000005", // key 0x1234, sequence 5
+		Factory:                       "0x3333333333333333333333333333333333333333",
+		FactoryData:                   "0xdeadbeef",
+		CallData:                      testCallData,
+		CallGasLimit:                  "100000",
+		VerificationGasLimit:          "200000",
+		PreVerificationGas:            "50000",
+		MaxFeePerGas:                  "3000000000",
+		MaxPriorityFeePerGas:          "1000000000",
+		Paymaster:                     "0x4444444444444444444444444444444444444444",
+		PaymasterVerificationGasLimit: "60000",
+		PaymasterPostOpGasLimit:       "70000",
+		PaymasterData:                 "0xcafe",
+	}
+}
+
+// The expected hashes were computed with an independent implementation of the EntryPoint
+// getUserOpHash encodings (v0.6 and v0.7 abi.encode, v0.8 EIP-712).
+func TestGetUserOpHash(t *testing.T) {
+	v06 := &types.BuildUserOpResponse{
+		Sender:               testSender,
+		Nonce:                "7",
+		Factory:              "0x3333333333333333333333333333333333333333",
+		FactoryData:          "0xdeadbeef",
+		CallData:             testCallData,
+		CallGasLimit:         "100000",
+		VerificationGasLimit: "200000",
+		PreVerificationGas:   "50000",
+		MaxFeePerGas:         "3000000000",
+		MaxPriorityFeePerGas: "1000000000",
+		PaymasterAndData:     "0x4444444444444444444444444444444444444444cafe",
+	}
+
+	// The same v0.6 user operation with unpacked paymaster fields.
+	v06Paymaster := *v06
+	v06Paymaster.PaymasterAndData = ""
+	v06Paymaster.Paymaster = "0x4444444444444444444444444444444444444444"
+	v06Paymaster.PaymasterData = "0xcafe"
+
+	eip7702 := testPackedOp()
+	eip7702.Factory = "0x7702"
+	eip7702.FactoryData = ""
+	eip7702.Authorization = &types.SignedAuthorization{Address: testDelegate}
+
+	eip7702WithData := testPackedOp()
+	eip7702WithData.Factory = "0x7702000000000000000000000000000000000000"
+	eip7702WithData.FactoryData = "0xabcd"
+	eip7702WithData.Authorization = &types.SignedAuthorization{Address: testDelegate}
+
+	tests := []struct {
+		name    string
+		op      *types.BuildUserOpResponse
+		version constants.EntryPointVersion
+		chainID uint64
+		want    string
+	}{
+		{"v0.6", v06, constants.EntryPointVersion06, 1, "0x73fe1839f12d73b0d2c3f7d328eebc7c4a2951a6ea794197969ca1082376ddc4"},
+		{"v0.6 unpacked paymaster", &v06Paymaster, constants.EntryPointVersion06, 1, "0x73fe1839f12d73b0d2c3f7d328eebc7c4a2951a6ea794197969ca1082376ddc4"},
+		{"v0.7", testPackedOp(), constants.EntryPointVersion07, 137, "0x28701115469065d8ff0cad10de3712fa74c316794009755fa0507f01dda6b3db"},
+		{"v0.8", testPackedOp(), constants.EntryPointVersion08, 8453, "0xf5fff24974693786bc2096f5ba601a95189546fd10173dd87f52d97d227d8110"},
+		{"v0.8 EIP-7702 marker", eip7702, constants.EntryPointVersion08, 8453, "0xc28e8279e25968fe3e342810858517dbc5890d87e094f9141c828d53f86abfb4"},
+		{"v0.8 EIP-7702 marker with init data", eip7702WithData, constants.EntryPointVersion08, 8453, "0x00b0c63e17baa11a1d56af3f76e6d95ed0883922368878b6caeea8768886fe65"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetUserOpHash(tt.op, tt.version, tt.chainID)
+			if err != nil {
+				t.Fatalf("GetUserOpHash() error = %v", err)
+			}
+			if got.Hex() != tt.want {
+				t.Errorf("GetUserOpHash() = %s, want %s", got.Hex(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUserOpHashPackedFields(t *testing.T) {
+	// The same user operation with packed gas and paymaster fields hashes identically.
+	op := &types.BuildUserOpResponse{
+		Sender:             testSender,
+		Nonce:              "0x12340000000000000005",
+		Factory:            "0x3333333333333333333333333333333333333333",
+		FactoryData:        "0xdeadbeef",
+		CallData:           testCallData,
+		AccountGasLimits:   "0x00000000000000000000000000030d40000000000000000000000000000186a0",
+		PreVerificationGas: "0xc350",
+		GasFees:            "0x0000000000000000000000003b9aca00000000000000000000000000b2d05e00",
+		PaymasterAndData:   "0x44444444444444444444444444444444444444440000000000000000000000000000ea6000000000000000000000000000011170cafe",
+	}
+
+	got, err := GetUserOpHash(op, constants.EntryPointVersion07, 137)
+	if err != nil {
+		t.Fatalf("GetUserOpHash() error = %v", err)
+	}
+	if want := "0x28701115469065d8ff0cad10de3712fa74c316794009755fa0507f01dda6b3db"; got.Hex() != want {
+		t.Errorf("GetUserOpHash() = %s, want %s", got.Hex(), want)
+	}
+}
+
+func TestGetUserOpHashEIP7702WithoutDelegate(t *testing.T) {
+	op := testPackedOp()
+	op.Factory = "0x7702"
+	op.FactoryData = ""
+
+	if _, err := GetUserOpHash(op, constants.EntryPointVersion08, 8453); err == nil {
+		t.Fatal("GetUserOpHash() succeeded without the EIP-7702 delegate")
+	}
+}
+
+func TestGetUserOpHashEIP7702Delegated(t *testing.T) {
+	// An already delegated EOA sends user operations with the marker and no authorization.
+	op := testPackedOp()
+	op.Factory = "0x7702"
+	op.FactoryData = ""
+	want := "0xc28e8279e25968fe3e342810858517dbc5890d87e094f9141c828d53f86abfb4"
+
+	got, err := GetUserOpHash(op, constants.EntryPointVersion08, 8453, WithDelegate(common.HexToAddress(testDelegate)))
+	if err != nil {
+		t.Fatalf("GetUserOpHash() error = %v", err)
+	}
+	if got.Hex() != want {
+		t.Errorf("GetUserOpHash() = %s, want %s", got.Hex(), want)
+	}
+
+	// The delegate of an authorization takes precedence over the current one.
+	op.Authorization = &types.SignedAuthorization{Address: testDelegate}
+	got, err = GetUserOpHash(op, constants.EntryPointVersion08, 8453, WithDelegate(common.HexToAddress(testSender)))
+	if err != nil {
+		t.Fatalf("GetUserOpHash() error = %v", err)
+	}
+	if got.Hex() != want {
+		t.Errorf("GetUserOpHash() with authorization = %s, want %s", got.Hex(), want)
+	}
+}
+
+func TestVerifyUserOpHash(t *testing.T) {
+	op := testPackedOp()
+	op.UserOpHash = "0x28701115469065d8ff0cad10de3712fa74c316794009755fa0507f01dda6b3db"
+	if _, err := VerifyUserOpHash(op, constants.EntryPointVersion07, 137); err != nil {
+		t.Fatalf("VerifyUserOpHash() error = %v", err)
+	}
+
+	if _, err := VerifyUserOpHash(op, constants.EntryPointVersion07, 1); !errors.Is(err, ErrUserOpHashMismatch) {
+		t.Errorf("VerifyUserOpHash() on another chain error = %v, want %v", err, ErrUserOpHashMismatch)
+	}
+}
@@ -0,0 +1,218 @@
+package userop
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// RPCAuthorization is the EIP-7702 authorization format used by ERC-4337 JSON-RPC methods.
+type RPCAuthorization struct {
+	ChainID string `json:"chainId"`
+	Address string `json:"address"`
+	Nonce   string `json:"nonce"`
+	YParity string `json:"yParity"`
+	R       string `json:"r"`
+	S       string `json:"s"`
+}
+
+// RPCUserOperation is the user operation format used by ERC-4337 and ERC-7677 JSON-RPC methods.
+// EntryPoint v0.7 and v0.8 use the unpacked factory and paymaster fields, v0.6 uses
+// InitCode and PaymasterAndData. All numeric fields are hex quantities.
+type RPCUserOperation struct {
+	Sender                        string            `json:"sender"`
+	Nonce                         string            `json:"nonce"`
+	Factory                       string            `json:"factory,omitempty"`
+	FactoryData                   string            `json:"factoryData,omitempty"`
+	InitCode                      string            `json:"initCode,omitempty"`
+	CallData                      string            `json:"callData"`
+	CallGasLimit                  string            `json:"callGasLimit"`
+	VerificationGasLimit          string            `json:"verificationGasLimit"`
+	PreVerificationGas            string            `json:"preVerificationGas"`
+	MaxFeePerGas                  string            `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas          string            `json:"maxPriorityFeePerGas"`
+	Paymaster                     string            `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit string            `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       string            `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 string            `json:"paymasterData,omitempty"`
+	PaymasterAndData              string            `json:"paymasterAndData,omitempty"`
+	Signature                     string            `json:"signature"`
+	Eip7702Auth                   *RPCAuthorization `json:"eip7702Auth,omitempty"`
+}
+
+// ToRPC converts a builder response and signature into the JSON-RPC user operation format
+// of the given EntryPoint version.
+func ToRPC(op *types.BuildUserOpResponse, signature string, entryPointVersion constants.EntryPointVersion) (*RPCUserOperation, error) {
+	if _, err := constants.GetEntryPointAddress(entryPointVersion); err != nil {
+		return nil, err
+	}
+
+	withSignature := *op
+	withSignature.Signature = signature
+	packed, err := Pack(&withSignature, entryPointVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack user operation: %w", err)
+	}
+
+	rpcOp := &RPCUserOperation{
+		Sender:               packed.Sender.Hex(),
+		Nonce:                hexutil.EncodeBig(packed.Nonce),
+		CallData:             hexutil.Encode(packed.CallData),
+		CallGasLimit:         hexutil.EncodeBig(packed.CallGasLimit()),
+		VerificationGasLimit: hexutil.EncodeBig(packed.VerificationGasLimit()),
+		PreVerificationGas:   hexutil.EncodeBig(packed.PreVerificationGas),
+		MaxFeePerGas:         hexutil.EncodeBig(packed.MaxFeePerGas()),
+		MaxPriorityFeePerGas: hexutil.EncodeBig(packed.MaxPriorityFeePerGas()),
+		Signature:            hexutil.Encode(packed.Signature),
+	}
+
+	if entryPointVersion == constants.EntryPointVersion06 {
+		rpcOp.InitCode = hexutil.Encode(packed.InitCode)
+		rpcOp.PaymasterAndData = hexutil.Encode(packed.PaymasterAndData)
+	} else {
+		if len(packed.InitCode) > 0 {
+			factoryLen := common.AddressLength
+			if len(packed.InitCode) < factoryLen {
+				factoryLen = len(packed.InitCode)
+			}
+			rpcOp.Factory = hexutil.Encode(packed.InitCode[:factoryLen])
+			rpcOp.FactoryData = hexutil.Encode(packed.InitCode[factoryLen:])
+		}
+		if len(packed.PaymasterAndData) > 0 {
+			if len(packed.PaymasterAndData) < 52 {
+				return nil, fmt.Errorf("invalid paymasterAndData length: expected at least 52 bytes, got %d", len(packed.PaymasterAndData))
+			}
+			rpcOp.Paymaster = common.BytesToAddress(packed.PaymasterAndData[:20]).Hex()
+			rpcOp.PaymasterVerificationGasLimit = hexutil.EncodeBig(new(big.Int).SetBytes(packed.PaymasterAndData[20:36]))
+			rpcOp.PaymasterPostOpGasLimit = hexutil.EncodeBig(new(big.Int).SetBytes(packed.PaymasterAndData[36:52]))
+			rpcOp.PaymasterData = hexutil.Encode(packed.PaymasterAndData[52:])
+		}
+	}
+
+	if op.Authorization != nil {
+		auth, err := ToRPCAuthorization(op.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		rpcOp.Eip7702Auth = auth
+	}
+
+	return rpcOp, nil
+}
+
+// FromRPC converts a JSON-RPC user operation into a builder response shaped user operation.
+// The signature is returned in the Signature field.
+func FromRPC(rpcOp *RPCUserOperation) (*types.BuildUserOpResponse, error) {
+	op := &types.BuildUserOpResponse{
+		Sender:                        rpcOp.Sender,
+		Nonce:                         rpcOp.Nonce,
+		CallData:                      rpcOp.CallData,
+		PreVerificationGas:            rpcOp.PreVerificationGas,
+		Signature:                     rpcOp.Signature,
+		Factory:                       rpcOp.Factory,
+		FactoryData:                   rpcOp.FactoryData,
+		CallGasLimit:                  rpcOp.CallGasLimit,
+		VerificationGasLimit:          rpcOp.VerificationGasLimit,
+		MaxFeePerGas:                  rpcOp.MaxFeePerGas,
+		MaxPriorityFeePerGas:          rpcOp.MaxPriorityFeePerGas,
+		Paymaster:                     rpcOp.Paymaster,
+		PaymasterVerificationGasLimit: rpcOp.PaymasterVerificationGasLimit,
+		PaymasterPostOpGasLimit:       rpcOp.PaymasterPostOpGasLimit,
+		PaymasterData:                 rpcOp.PaymasterData,
+		PaymasterAndData:              rpcOp.PaymasterAndData,
+	}
+
+	// v0.6 operations carry the packed init code instead of factory and factoryData.
+	if rpcOp.InitCode != "" && rpcOp.Factory == "" {
+		if initCode, err := hexutil.Decode(rpcOp.InitCode); err == nil && len(initCode) >= common.AddressLength {
+			op.Factory = common.BytesToAddress(initCode[:common.AddressLength]).Hex()
+			op.FactoryData = hexutil.Encode(initCode[common.AddressLength:])
+		}
+	}
+
+	if rpcOp.Eip7702Auth != nil {
+		auth, err := FromRPCAuthorization(rpcOp.Eip7702Auth)
+		if err != nil {
+			return nil, err
+		}
+		op.Authorization = auth
+	}
+
+	return op, nil
+}
+
+// ToRPCAuthorization converts a signed EIP-7702 authorization into its JSON-RPC format.
+func ToRPCAuthorization(auth *types.SignedAuthorization) (*RPCAuthorization, error) {
+	if !common.IsHexAddress(auth.Address) {
+		return nil, fmt.Errorf("invalid authorization address: %q", auth.Address)
+	}
+	r, err := ParseUint256(auth.R)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization r: %w", err)
+	}
+	s, err := ParseUint256(auth.S)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization s: %w", err)
+	}
+
+	return &RPCAuthorization{
+		ChainID: hexutil.EncodeUint64(auth.ChainID),
+		Address: common.HexToAddress(auth.Address).Hex(),
+		Nonce:   hexutil.EncodeUint64(auth.Nonce),
+		YParity: hexutil.EncodeUint64(uint64(auth.YParity)),
+		R:       hexutil.EncodeBig(r),
+		S:       hexutil.EncodeBig(s),
+	}, nil
+}
+
+// FromRPCAuthorization converts a JSON-RPC EIP-7702 authorization into a SignedAuthorization.
+// Returns an error when a numeric field is malformed or out of range, so that a bad chain id
+// cannot turn into a chain-agnostic (chain id 0) authorization.
+func FromRPCAuthorization(auth *RPCAuthorization) (*types.SignedAuthorization, error) {
+	if !common.IsHexAddress(auth.Address) {
+		return nil, fmt.Errorf("invalid authorization address: %q", auth.Address)
+	}
+	chainID, err := parseUint64(auth.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization chainId: %w", err)
+	}
+	nonce, err := parseUint64(auth.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization nonce: %w", err)
+	}
+	yParity, err := parseUint64(auth.YParity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization yParity: %w", err)
+	}
+	if yParity > 1 {
+		return nil, fmt.Errorf("invalid authorization yParity: %d", yParity)
+	}
+
+	return &types.SignedAuthorization{
+		ChainID: chainID,
+		Address: auth.Address,
+		Nonce:   nonce,
+		R:       auth.R,
+		S:       auth.S,
+		YParity: uint8(yParity),
+	}, nil
+}
+
+// parseUint64 parses a required hex or decimal quantity that must fit in 64 bits.
+func parseUint64(value string) (uint64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("missing value")
+	}
+	n, err := ParseUint256(value)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("value out of uint64 range: %q", value)
+	}
+	return n.Uint64(), nil
+}
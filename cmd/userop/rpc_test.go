@@ -0,0 +1,83 @@
+package userop
+
+import (
+	"testing"
+
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+)
+
+func TestFromRPCAuthorization(t *testing.T) {
+	valid := RPCAuthorization{
+		ChainID: "0x2105",
+		Address: testDelegate,
+		Nonce:   "0x7",
+		YParity: "0x1",
+		R:       "0x01",
+		S:       "0x02",
+	}
+
+	auth, err := FromRPCAuthorization(&valid)
+	if err != nil {
+		t.Fatalf("FromRPCAuthorization() error = %v", err)
+	}
+	if auth.ChainID != 8453 || auth.Nonce != 7 || auth.YParity != 1 {
+		t.Errorf("FromRPCAuthorization() = %+v", auth)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*RPCAuthorization)
+	}{
+		{"malformed chainId", func(a *RPCAuthorization) { a.ChainID = "0xzz" }},
+		{"missing chainId", func(a *RPCAuthorization) { a.ChainID = "" }},
+		{"chainId over 64 bits", func(a *RPCAuthorization) { a.ChainID = "0x10000000000000000" }},
+		{"malformed nonce", func(a *RPCAuthorization) { a.Nonce = "seven" }},
+		{"nonce over 64 bits", func(a *RPCAuthorization) { a.Nonce = "0x10000000000000007" }},
+		{"yParity out of range", func(a *RPCAuthorization) { a.YParity = "0x1b" }},
+		{"invalid address", func(a *RPCAuthorization) { a.Address = "0x1234" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := valid
+			tt.modify(&auth)
+			if _, err := FromRPCAuthorization(&auth); err == nil {
+				t.Error("FromRPCAuthorization() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestToRPCPaymaster(t *testing.T) {
+	tests := []struct {
+		name    string
+		version constants.EntryPointVersion
+		want    RPCUserOperation
+	}{
+		{"v0.6", constants.EntryPointVersion06, RPCUserOperation{
+			PaymasterAndData: "0x4444444444444444444444444444444444444444cafe",
+		}},
+		{"v0.7", constants.EntryPointVersion07, RPCUserOperation{
+			Paymaster:                     "0x4444444444444444444444444444444444444444",
+			PaymasterVerificationGasLimit: "0xea60",
+			PaymasterPostOpGasLimit:       "0x11170",
+			PaymasterData:                 "0xcafe",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToRPC(testPackedOp(), "0x", tt.version)
+			if err != nil {
+				t.Fatalf("ToRPC() error = %v", err)
+			}
+			if got.Paymaster != tt.want.Paymaster ||
+				got.PaymasterVerificationGasLimit != tt.want.PaymasterVerificationGasLimit ||
+				got.PaymasterPostOpGasLimit != tt.want.PaymasterPostOpGasLimit ||
+				got.PaymasterData != tt.want.PaymasterData ||
+				got.PaymasterAndData != tt.want.PaymasterAndData {
+				t.Errorf("ToRPC() paymaster fields = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package userop packs builder responses into EntryPoint user operations and computes their hashes locally.
+package userop
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// eip7702InitCodeMarker is the factory value used by EntryPoint v0.8 to flag EIP-7702 accounts.
+var eip7702InitCodeMarker = common.HexToAddress("0x7702000000000000000000000000000000000000")
+
+// PackedUserOperation represents the EntryPoint v0.7 PackedUserOperation struct.
+type PackedUserOperation struct {
+	Sender             common.Address
+	Nonce              *big.Int
+	InitCode           []byte
+	CallData           []byte
+	AccountGasLimits   [32]byte
+	PreVerificationGas *big.Int
+	GasFees            [32]byte
+	PaymasterAndData   []byte
+	Signature          []byte
+}
+
+// Pack converts a builder response into a PackedUserOperation for the given EntryPoint version.
+// Unpacked gas and paymaster fields take precedence over their packed counterparts when both are present.
+// EntryPoint v0.6 user operations keep the v0.6 paymasterAndData layout (paymaster || paymasterData).
+func Pack(op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion) (*PackedUserOperation, error) {
+	if op == nil {
+		return nil, fmt.Errorf("user operation is nil")
+	}
+	if !common.IsHexAddress(op.Sender) {
+		return nil, fmt.Errorf("invalid sender address: %q", op.Sender)
+	}
+
+	nonce, err := ParseUint256(op.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	preVerificationGas, err := ParseUint256(op.PreVerificationGas)
+	if err != nil {
+		return nil, fmt.Errorf("invalid preVerificationGas: %w", err)
+	}
+	callData, err := decodeHex(op.CallData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid callData: %w", err)
+	}
+	signature, err := decodeHex(op.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	initCode, err := packInitCode(op.Factory, op.FactoryData)
+	if err != nil {
+		return nil, err
+	}
+
+	accountGasLimits, err := packGasPair(op.VerificationGasLimit, op.CallGasLimit, op.AccountGasLimits, "accountGasLimits")
+	if err != nil {
+		return nil, err
+	}
+	gasFees, err := packGasPair(op.MaxPriorityFeePerGas, op.MaxFeePerGas, op.GasFees, "gasFees")
+	if err != nil {
+		return nil, err
+	}
+
+	paymasterAndData, err := packPaymasterAndData(op, entryPointVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PackedUserOperation{
+		Sender:             common.HexToAddress(op.Sender),
+		Nonce:              nonce,
+		InitCode:           initCode,
+		CallData:           callData,
+		AccountGasLimits:   accountGasLimits,
+		PreVerificationGas: preVerificationGas,
+		GasFees:            gasFees,
+		PaymasterAndData:   paymasterAndData,
+		Signature:          signature,
+	}, nil
+}
+
+// VerificationGasLimit returns the high 128 bits of AccountGasLimits.
+func (p *PackedUserOperation) VerificationGasLimit() *big.Int {
+	return new(big.Int).SetBytes(p.AccountGasLimits[:16])
+}
+
+// CallGasLimit returns the low 128 bits of AccountGasLimits.
+func (p *PackedUserOperation) CallGasLimit() *big.Int {
+	return new(big.Int).SetBytes(p.AccountGasLimits[16:])
+}
+
+// MaxPriorityFeePerGas returns the high 128 bits of GasFees.
+func (p *PackedUserOperation) MaxPriorityFeePerGas() *big.Int {
+	return new(big.Int).SetBytes(p.GasFees[:16])
+}
+
+// MaxFeePerGas returns the low 128 bits of GasFees.
+func (p *PackedUserOperation) MaxFeePerGas() *big.Int {
+	return new(big.Int).SetBytes(p.GasFees[16:])
+}
+
+// IsEip7702InitCode reports whether the init code carries the EntryPoint v0.8 EIP-7702 marker.
+func (p *PackedUserOperation) IsEip7702InitCode() bool {
+	return len(p.InitCode) >= 2 && bytes.Equal(rightPad(p.InitCode, 20)[:20], eip7702InitCodeMarker.Bytes())
+}
+
+// ParseUint256 parses a 0x-prefixed hex or decimal string into a big.Int.
+// Empty strings and "0x" are treated as zero.
+func ParseUint256(value string) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0x" || value == "0X" {
+		return new(big.Int), nil
+	}
+
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		n, ok = n.SetString(value[2:], 16)
+	} else {
+		n, ok = n.SetString(value, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid numeric value: %q", value)
+	}
+	if n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, fmt.Errorf("value out of uint256 range: %q", value)
+	}
+	return n, nil
+}
+
+func packInitCode(factory, factoryData string) ([]byte, error) {
+	if factory == "" || factory == "0x" {
+		return []byte{}, nil
+	}
+	data, err := decodeHex(factoryData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid factoryData: %w", err)
+	}
+	if !common.IsHexAddress(factory) {
+		// The EIP-7702 marker may be returned in its short "0x7702" form.
+		if !strings.EqualFold(factory, "0x7702") {
+			return nil, fmt.Errorf("invalid factory address: %q", factory)
+		}
+		return append([]byte{0x77, 0x02}, data...), nil
+	}
+	return append(common.HexToAddress(factory).Bytes(), data...), nil
+}
+
+func packGasPair(high, low, packed, name string) ([32]byte, error) {
+	var out [32]byte
+	if high == "" && low == "" {
+		raw, err := decodeHex(packed)
+		if err != nil {
+			return out, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if len(raw) > 32 {
+			return out, fmt.Errorf("invalid %s length: expected at most 32 bytes, got %d", name, len(raw))
+		}
+		copy(out[32-len(raw):], raw)
+		return out, nil
+	}
+
+	h, err := parseUint128(high)
+	if err != nil {
+		return out, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	l, err := parseUint128(low)
+	if err != nil {
+		return out, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	h.FillBytes(out[:16])
+	l.FillBytes(out[16:])
+	return out, nil
+}
+
+// packPaymasterAndData returns paymaster || paymasterData for EntryPoint v0.6, and
+// paymaster || paymasterVerificationGasLimit || paymasterPostOpGasLimit || paymasterData for later versions.
+func packPaymasterAndData(op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion) ([]byte, error) {
+	if op.Paymaster == "" || op.Paymaster == "0x" {
+		data, err := decodeHex(op.PaymasterAndData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid paymasterAndData: %w", err)
+		}
+		return data, nil
+	}
+	if !common.IsHexAddress(op.Paymaster) {
+		return nil, fmt.Errorf("invalid paymaster address: %q", op.Paymaster)
+	}
+	data, err := decodeHex(op.PaymasterData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paymasterData: %w", err)
+	}
+	if entryPointVersion == constants.EntryPointVersion06 {
+		return append(common.HexToAddress(op.Paymaster).Bytes(), data...), nil
+	}
+
+	verificationGas, err := parseUint128(op.PaymasterVerificationGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paymasterVerificationGasLimit: %w", err)
+	}
+	postOpGas, err := parseUint128(op.PaymasterPostOpGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paymasterPostOpGasLimit: %w", err)
+	}
+
+	out := make([]byte, 52, 52+len(data))
+	copy(out[:20], common.HexToAddress(op.Paymaster).Bytes())
+	verificationGas.FillBytes(out[20:36])
+	postOpGas.FillBytes(out[36:52])
+	return append(out, data...), nil
+}
+
+func parseUint128(value string) (*big.Int, error) {
+	n, err := ParseUint256(value)
+	if err != nil {
+		return nil, err
+	}
+	if n.BitLen() > 128 {
+		return nil, fmt.Errorf("value out of uint128 range: %q", value)
+	}
+	return n, nil
+}
+
+func decodeHex(value string) ([]byte, error) {
+	if value == "" {
+		return []byte{}, nil
+	}
+	return hexutil.Decode(value)
+}
+
+func rightPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(append([]byte{}, b...), make([]byte, size-len(b))...)
+}
@@ -9,59 +9,89 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/zerodevapp/sdk-go/cmd/constants"
 	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// Builder API endpoint names, as used in APIError.Endpoint and WithEndpointRetryMode.
+const (
+	EndpointInitKernelClient = "init-kernel-client"
+	EndpointBuildUserOp      = "build-userop"
+	EndpointSendUserOp       = "send-userop"
+	EndpointGetUserOpReceipt = "get-userop-receipt"
 )
 
 // UseropBuilderClient represents a UserOp Builder API client.
 type UseropBuilderClient struct {
-	projectID  string
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	projectID   string
+	baseURL     string
+	apiKey      string
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+	retryModes  map[string]RetryMode
+	middlewares []Middleware
 }
 
-// NewUserOpBuilder creates a new UserOp Builder API client with default HTTP client.
-func NewUserOpBuilder(projectID string, baseURL string, apiKey string) *UseropBuilderClient {
-	return &UseropBuilderClient{
-		projectID: projectID,
-		baseURL:   baseURL,
-		apiKey:    apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// Option configures optional UseropBuilderClient behavior.
+type Option func(*UseropBuilderClient)
+
+// WithRetryPolicy sets the retry policy used for all endpoints. Use NoRetry to disable retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *UseropBuilderClient) {
+		c.retryPolicy = policy
 	}
 }
 
-// NewUserOpBuilderWithHTTPClient creates a new client with a custom HTTP client.
-func NewUserOpBuilderWithHTTPClient(projectID string, baseURL string, apiKey string, httpClient *http.Client) *UseropBuilderClient {
-	return &UseropBuilderClient{
-		projectID:  projectID,
-		baseURL:    baseURL,
-		apiKey:     apiKey,
-		httpClient: httpClient,
+// WithEndpointRetryMode overrides the retry mode of a single endpoint.
+func WithEndpointRetryMode(endpoint string, mode RetryMode) Option {
+	return func(c *UseropBuilderClient) {
+		c.retryModes[endpoint] = mode
 	}
 }
 
-// InitialiseKernelClient initializes the kernel client for a specific chain.
-func (c *UseropBuilderClient) InitialiseKernelClient(chainID uint64, ctx context.Context) (bool, error) {
-	url := fmt.Sprintf("%s/%s/%d/init-kernel-client", c.baseURL, c.projectID, chainID)
+// NewUserOpBuilder creates a new UserOp Builder API client with default HTTP client.
+func NewUserOpBuilder(projectID string, baseURL string, apiKey string, opts ...Option) *UseropBuilderClient {
+	return NewUserOpBuilderWithHTTPClient(projectID, baseURL, apiKey, &http.Client{
+		Timeout: 30 * time.Second,
+	}, opts...)
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, nil)
-	if err != nil {
-		return false, fmt.Errorf("failed to create request: %w", err)
+// NewUserOpBuilderWithHTTPClient creates a new client with a custom HTTP client.
+func NewUserOpBuilderWithHTTPClient(projectID string, baseURL string, apiKey string, httpClient *http.Client, opts ...Option) *UseropBuilderClient {
+	c := &UseropBuilderClient{
+		projectID:   projectID,
+		baseURL:     baseURL,
+		apiKey:      apiKey,
+		httpClient:  httpClient,
+		retryPolicy: DefaultRetryPolicy(),
+		retryModes:  make(map[string]RetryMode, len(DefaultEndpointRetryModes)),
+	}
+	for endpoint, mode := range DefaultEndpointRetryModes {
+		c.retryModes[endpoint] = mode
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-KEY", c.apiKey)
+	if len(c.middlewares) > 0 {
+		// Wrap a copy so the caller's HTTP client is left untouched.
+		wrapped := *c.httpClient
+		wrapped.Transport = chain(wrapped.Transport, c.middlewares)
+		c.httpClient = &wrapped
+	}
+	return c
+}
 
-	resp, err := c.httpClient.Do(httpReq)
+// InitialiseKernelClient initializes the kernel client for a specific chain.
+func (c *UseropBuilderClient) InitialiseKernelClient(chainID uint64, ctx context.Context) (bool, error) {
+	resp, _, err := c.post(ctx, chainID, EndpointInitKernelClient, nil, false)
 	if err != nil {
-		return false, fmt.Errorf("failed to send request: %w", err)
+		return false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return false, newAPIError(resp, EndpointInitKernelClient)
 	}
 
 	var result types.BuildUserOpResponse
@@ -74,29 +104,19 @@ func (c *UseropBuilderClient) InitialiseKernelClient(chainID uint64, ctx context
 
 // BuildUserOp builds a user operation with the provided parameters.
 func (c *UseropBuilderClient) BuildUserOp(ctx context.Context, chainID uint64, req *types.BuildUserOpRequest) (*types.BuildUserOpResponse, error) {
-	url := fmt.Sprintf("%s/%s/%d/build-userop", c.baseURL, c.projectID, chainID)
-
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-KEY", c.apiKey)
-
-	resp, err := c.httpClient.Do(httpReq)
+	resp, _, err := c.post(ctx, chainID, EndpointBuildUserOp, body, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, newAPIError(resp, EndpointBuildUserOp)
 	}
 
 	var result types.BuildUserOpResponse
@@ -108,30 +128,29 @@ func (c *UseropBuilderClient) BuildUserOp(ctx context.Context, chainID uint64, r
 }
 
 // SendUserOp sends a user operation to the bundler.
+// Transient failures are only retried when req.UserOpHash matches the hash computed locally from the
+// user operation, so that a resubmission the bundler reports as already known can be recognized as the
+// original submission.
 func (c *UseropBuilderClient) SendUserOp(ctx context.Context, chainID uint64, req *types.SendUserOpRequest) (*types.SendUserOpResponse, error) {
-	url := fmt.Sprintf("%s/%s/%d/send-userop", c.baseURL, c.projectID, chainID)
-
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-KEY", c.apiKey)
-
-	resp, err := c.httpClient.Do(httpReq)
+	_, hashErr := userop.VerifyUserOpHash(&req.BuildUserOpResponse, constants.EntryPointVersion(req.EntryPointVersion), chainID)
+	deduplicated := hashErr == nil
+	resp, attempts, err := c.post(ctx, chainID, EndpointSendUserOp, body, deduplicated)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		apiErr := newAPIError(resp, EndpointSendUserOp)
+		if attempts > 1 && deduplicated && apiErr.isDuplicateSubmission() {
+			return &types.SendUserOpResponse{UserOpHash: req.UserOpHash}, nil
+		}
+		return nil, apiErr
 	}
 
 	var result types.SendUserOpResponse
@@ -144,29 +163,19 @@ func (c *UseropBuilderClient) SendUserOp(ctx context.Context, chainID uint64, re
 
 // GetUserOpReceipt gets the receipt for a user operation by hash.
 func (c *UseropBuilderClient) GetUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest) (*types.UserOpReceipt, error) {
-	url := fmt.Sprintf("%s/%s/%d/get-userop-receipt", c.baseURL, c.projectID, chainID)
-
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	resp, _, err := c.post(ctx, chainID, EndpointGetUserOpReceipt, body, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-KEY", c.apiKey)
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, newAPIError(resp, EndpointGetUserOpReceipt)
 	}
 
 	// Try to decode as receipt first
@@ -183,7 +192,7 @@ func (c *UseropBuilderClient) GetUserOpReceipt(ctx context.Context, chainID uint
 	}
 
 	if _, hasError := errorCheck["error"]; hasError {
-		return nil, fmt.Errorf("receipt not found yet")
+		return nil, ErrReceiptNotFound
 	}
 
 	if err := json.Unmarshal(bodyBytes, &result); err != nil {
@@ -193,6 +202,70 @@ func (c *UseropBuilderClient) GetUserOpReceipt(ctx context.Context, chainID uint
 	return &result, nil
 }
 
+// post sends a JSON request to an endpoint, retrying failures allowed by the endpoint's retry mode
+// and the client's retry policy. It returns the last response and the number of attempts made.
+// deduplicated reports whether resubmitting the request is detectable by the server.
+func (c *UseropBuilderClient) post(ctx context.Context, chainID uint64, endpoint string, body []byte, deduplicated bool) (*http.Response, int, error) {
+	url := fmt.Sprintf("%s/%s/%d/%s", c.baseURL, c.projectID, chainID, endpoint)
+	mode := c.retryModes[endpoint]
+	ctx = context.WithValue(ctx, endpointContextKey, endpoint)
+	if RequestIDFromContext(ctx) == "" {
+		// One ID per call, so that every attempt of the call can be linked in logs.
+		ctx = WithRequestID(ctx, newRequestID())
+	}
+
+	for attempt := 1; ; attempt++ {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bodyReader)
+		if err != nil {
+			return nil, attempt, fmt.Errorf("failed to create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("X-API-KEY", c.apiKey)
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err == nil && resp.StatusCode < 400 {
+			return resp, attempt, nil
+		}
+
+		delay, retry := c.shouldRetry(mode, deduplicated, attempt, resp, err)
+		if !retry {
+			if err != nil {
+				return nil, attempt, fmt.Errorf("failed to send request: %w", err)
+			}
+			return resp, attempt, nil
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, attempt, fmt.Errorf("failed to send request: %w", err)
+		}
+	}
+}
+
+// shouldRetry applies the endpoint retry mode before consulting the retry policy.
+func (c *UseropBuilderClient) shouldRetry(mode RetryMode, deduplicated bool, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if c.retryPolicy == nil {
+		return 0, false
+	}
+	switch mode {
+	case RetryNever:
+		return 0, false
+	case RetryIfDeduplicated:
+		// A 429 means the request was rejected before processing, so it is always safe to repeat.
+		if !deduplicated && (resp == nil || resp.StatusCode != http.StatusTooManyRequests) {
+			return 0, false
+		}
+	}
+	return c.retryPolicy.Retry(attempt, resp, err)
+}
+
 // WaitForUserOpReceipt polls for the user operation receipt until it's available or timeout is reached.
 func (c *UseropBuilderClient) WaitForUserOpReceipt(ctx context.Context, chainID uint64, req *types.GetUserOpReceiptRequest, pollInterval time.Duration, timeout time.Duration) (*types.UserOpReceipt, error) {
 	if pollInterval == 0 {
@@ -221,10 +294,11 @@ func (c *UseropBuilderClient) WaitForUserOpReceipt(ctx context.Context, chainID
 	for {
 		select {
 		case <-timeoutCtx.Done():
-			return nil, fmt.Errorf("timed out waiting for user operation receipt after %d attempts", attemptNum-1)
+			return nil, fmt.Errorf("timed out waiting for user operation receipt after %d attempts: %w", attemptNum-1, err)
 
 		case <-ticker.C:
-			receipt, err := c.GetUserOpReceipt(timeoutCtx, chainID, req)
+			var receipt *types.UserOpReceipt
+			receipt, err = c.GetUserOpReceipt(timeoutCtx, chainID, req)
 			if err == nil {
 
 				return receipt, nil
@@ -0,0 +1,203 @@
+package useropbuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zerodevapp/sdk-go/cmd/entrypoint"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+)
+
+// Sentinel errors returned by UseropBuilderClient. Use errors.Is to match them.
+// ErrValidationFailed and ErrReceiptNotFound are the shared errors of the types package.
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrValidationFailed = types.ErrValidationFailed
+	ErrServerError      = errors.New("server error")
+	ErrReceiptNotFound  = types.ErrReceiptNotFound
+)
+
+// APIError represents a non-successful response from the UserOp Builder API.
+type APIError struct {
+	StatusCode int             // HTTP status code
+	Endpoint   string          // Endpoint name, e.g. EndpointBuildUserOp
+	RequestID  string          // X-Request-Id of the response, or of the request when the response has none
+	Message    string          // Error message parsed from the response body
+	Code       string          // Error code parsed from the response body, if any
+	Details    json.RawMessage // Additional error data parsed from the response body, if any
+	Body       string          // Raw response body
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Body
+	}
+	s := fmt.Sprintf("%s: unexpected status code %d: %s", e.Endpoint, e.StatusCode, msg)
+	if e.RequestID != "" {
+		s += fmt.Sprintf(" (request id %s)", e.RequestID)
+	}
+	return s
+}
+
+// Unwrap returns the sentinel error matching the status code and body, followed by the decoded
+// EntryPoint error when the body carries one, so errors.Is and errors.As work on APIError.
+func (e *APIError) Unwrap() []error {
+	var errs []error
+	if sentinel := e.sentinel(); sentinel != nil {
+		errs = append(errs, sentinel)
+	}
+	if epErr := e.EntryPointError(); epErr != nil {
+		errs = append(errs, epErr)
+	}
+	return errs
+}
+
+// EntryPointError returns the EntryPoint FailedOp error carried in the response body, if any.
+func (e *APIError) EntryPointError() *entrypoint.EntryPointError {
+	for _, s := range []string{e.Message, string(e.Details), e.Body} {
+		if epErr, ok := entrypoint.ParseEntryPointError(s); ok {
+			return epErr
+		}
+	}
+	return nil
+}
+
+func (e *APIError) sentinel() error {
+	if e.isValidationFailure() {
+		return ErrValidationFailed
+	}
+
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= 500:
+		return ErrServerError
+	case e.StatusCode >= 400:
+		return ErrBadRequest
+	default:
+		return nil
+	}
+}
+
+// isValidationFailure reports whether the error is a bundler or EntryPoint validation rejection.
+func (e *APIError) isValidationFailure() bool {
+	if e.StatusCode < 400 || e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests {
+		return false
+	}
+	if validationErrorCodes[e.Code] {
+		return true
+	}
+	return e.EntryPointError() != nil
+}
+
+// validationErrorCodes are the ERC-4337 bundler RPC codes rejecting a user operation, -32500 through -32507.
+var validationErrorCodes = map[string]bool{
+	"-32500": true, // Rejected by EntryPoint simulateValidation
+	"-32501": true, // Rejected by paymaster validatePaymasterUserOp
+	"-32502": true, // Opcode validation
+	"-32503": true, // Out of time range
+	"-32504": true, // Paymaster or aggregator throttled or banned
+	"-32505": true, // Paymaster or aggregator stake or unstake delay too low
+	"-32506": true, // Unsupported signature aggregator
+	"-32507": true, // Invalid signature
+}
+
+// isDuplicateSubmission reports whether the bundler rejected the user operation because it already has it.
+// Bundlers report this as an invalid params JSON-RPC error (-32602) with an "already known" message.
+func (e *APIError) isDuplicateSubmission() bool {
+	return e.Code == "-32602" && strings.Contains(strings.ToLower(e.Message), "already known")
+}
+
+// errorBody covers the error response shapes returned by the builder service and proxied bundlers.
+type errorBody struct {
+	Message string          `json:"message"`
+	Error   json.RawMessage `json:"error"`
+	Code    json.RawMessage `json:"code"`
+	Details json.RawMessage `json:"details"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// newAPIError reads the response body and builds an APIError for the given endpoint.
+func newAPIError(resp *http.Response, endpoint string) *APIError {
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Body:       string(bodyBytes),
+	}
+	if apiErr.RequestID == "" && resp.Request != nil {
+		apiErr.RequestID = resp.Request.Header.Get("X-Request-Id")
+	}
+	apiErr.parseBody(bodyBytes)
+
+	return apiErr
+}
+
+// parseBody fills Message, Code and Details from a JSON error body, leaving them empty otherwise.
+func (e *APIError) parseBody(bodyBytes []byte) {
+	var body errorBody
+	if err := json.Unmarshal(bodyBytes, &body); err != nil {
+		return
+	}
+
+	e.Message = body.Message
+	e.Code = rawToString(body.Code)
+	e.Details = body.Details
+	if len(e.Details) == 0 {
+		e.Details = body.Data
+	}
+
+	if len(body.Error) == 0 {
+		return
+	}
+
+	// "error" is either a plain string or a nested JSON-RPC style error object.
+	var errString string
+	if err := json.Unmarshal(body.Error, &errString); err == nil {
+		if e.Message == "" {
+			e.Message = errString
+		}
+		return
+	}
+	var nested errorBody
+	if err := json.Unmarshal(body.Error, &nested); err == nil {
+		if nested.Message != "" {
+			e.Message = nested.Message
+		}
+		if code := rawToString(nested.Code); code != "" {
+			e.Code = code
+		}
+		if len(nested.Data) > 0 {
+			e.Details = nested.Data
+		}
+	}
+}
+
+func rawToString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
@@ -0,0 +1,192 @@
+package useropbuilder
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+)
+
+// Middleware wraps the transport used for every HTTP attempt made by the client,
+// including retries. Middlewares see the fully built request with default headers set.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts an ordinary function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware appends middlewares to the client's chain. The first middleware is the outermost.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *UseropBuilderClient) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// chain wraps base with the middlewares so that middlewares[0] runs first.
+func chain(base http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+type contextKey int
+
+const (
+	endpointContextKey contextKey = iota
+	requestIDContextKey
+)
+
+// EndpointFromContext returns the builder endpoint name of the request being sent, for use in middlewares.
+func EndpointFromContext(ctx context.Context) string {
+	endpoint, _ := ctx.Value(endpointContextKey).(string)
+	return endpoint
+}
+
+// WithRequestID returns a context carrying a request ID that RequestIDMiddleware sends to the builder.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID set with WithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// UserAgentMiddleware sets the User-Agent and X-SDK-Version headers.
+// product, when not empty, is prepended to the SDK user agent, e.g. "payments-worker/1.2".
+func UserAgentMiddleware(product string) Middleware {
+	userAgent := "zerodev-sdk-go/" + constants.SDKVersion
+	if product != "" {
+		userAgent = product + " " + userAgent
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", userAgent)
+			req.Header.Set("X-SDK-Version", constants.SDKVersion)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// RequestIDMiddleware sets the X-Request-Id header from the context. The client generates an ID for
+// each call whose context carries none, so every attempt of a call is sent with the same ID.
+func RequestIDMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requestID := RequestIDFromContext(req.Context())
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Request-Id", requestID)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// maxLoggedBody caps the number of body bytes included in debug logs.
+const maxLoggedBody = 4096
+
+// redactedHeaders lists headers whose values are never logged.
+var redactedHeaders = []string{"X-API-KEY", "Authorization", "Proxy-Authorization"}
+
+// LoggingMiddleware logs every request and response with the given logger, redacting credentials.
+// Headers and bodies are only logged at debug level.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			debug := logger.Enabled(ctx, slog.LevelDebug)
+
+			attrs := []any{
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.String()),
+				slog.String("endpoint", EndpointFromContext(ctx)),
+			}
+			if debug {
+				req = req.Clone(ctx)
+				requestAttrs := []any{slog.Any("headers", redactHeaders(req.Header))}
+				if body, err := peekBody(&req.Body); err == nil {
+					requestAttrs = append(requestAttrs, slog.String("body", body))
+				}
+				logger.DebugContext(ctx, "userop builder request", append(attrs, requestAttrs...)...)
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+			if err != nil {
+				logger.WarnContext(ctx, "userop builder request failed", append(attrs, slog.Any("error", err))...)
+				return resp, err
+			}
+
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			if debug {
+				attrs = append(attrs, slog.Any("headers", redactHeaders(resp.Header)))
+				if body, err := peekBody(&resp.Body); err == nil {
+					attrs = append(attrs, slog.String("body", body))
+				}
+			}
+			level := slog.LevelInfo
+			if resp.StatusCode >= 400 {
+				level = slog.LevelWarn
+			}
+			logger.Log(ctx, level, "userop builder response", attrs...)
+
+			return resp, nil
+		})
+	}
+}
+
+// redactHeaders returns a copy of the headers with credential values replaced.
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "[REDACTED]")
+		}
+	}
+	return redacted
+}
+
+// peekBody reads the body for logging and replaces it with an unread copy.
+func peekBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	*body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	s := string(data)
+	if len(s) > maxLoggedBody {
+		s = s[:maxLoggedBody] + "...(truncated)"
+	}
+	return strings.TrimSpace(s), nil
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,147 @@
+package useropbuilder
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether a failed request is retried and how long to wait before retrying.
+type RetryPolicy interface {
+	// Retry is called after every failed attempt. attempt is the number of attempts made so far,
+	// resp is the response (nil on transport errors) and err the transport error (nil otherwise).
+	// It returns the delay before the next attempt and whether to retry at all.
+	Retry(attempt int, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// RetryMode describes which failures of an endpoint are safe to retry.
+type RetryMode int
+
+// Supported retry modes
+const (
+	// RetryIdempotent retries any transient failure. Used for endpoints without side effects.
+	RetryIdempotent RetryMode = iota
+	// RetryIfDeduplicated retries transient failures only when the request can be deduplicated
+	// by its userOpHash; otherwise only requests rejected before processing (429) are retried.
+	RetryIfDeduplicated
+	// RetryNever disables retries for the endpoint.
+	RetryNever
+)
+
+// DefaultEndpointRetryModes holds the retry mode of each builder endpoint.
+var DefaultEndpointRetryModes = map[string]RetryMode{
+	EndpointInitKernelClient: RetryIdempotent,
+	EndpointBuildUserOp:      RetryIdempotent,
+	EndpointSendUserOp:       RetryIfDeduplicated,
+	EndpointGetUserOpReceipt: RetryIdempotent,
+}
+
+// ExponentialBackoff is a RetryPolicy with exponential backoff, jitter and Retry-After support.
+// Transport errors, 429 and 5xx responses (except 501) are retried. A response asking to retry after
+// more than MaxInterval is not retried, so that a server cannot stall the caller for an arbitrary time.
+type ExponentialBackoff struct {
+	MaxAttempts     int           // Total attempts including the first one
+	InitialInterval time.Duration // Delay before the first retry
+	MaxInterval     time.Duration // Upper bound for the computed delay and the accepted Retry-After
+	Multiplier      float64       // Growth factor between consecutive delays
+	Jitter          float64       // Fraction of the delay randomized in both directions, from 0 to 1
+}
+
+// DefaultRetryPolicy returns the retry policy used by new clients.
+func DefaultRetryPolicy() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		MaxAttempts:     4,
+		InitialInterval: 250 * time.Millisecond,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+	}
+}
+
+// NoRetry returns a retry policy that never retries.
+func NoRetry() RetryPolicy {
+	return &ExponentialBackoff{MaxAttempts: 1}
+}
+
+// Retry implements RetryPolicy.
+func (b *ExponentialBackoff) Retry(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+	} else if resp == nil || !isRetryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if b.MaxInterval > 0 && retryAfter > b.MaxInterval {
+				return 0, false
+			}
+			return retryAfter, true
+		}
+	}
+
+	return b.backoff(attempt), true
+}
+
+// backoff returns the jittered exponential delay after the given number of attempts.
+func (b *ExponentialBackoff) backoff(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(b.InitialInterval) * math.Pow(multiplier, float64(attempt-1))
+	if b.MaxInterval > 0 && delay > float64(b.MaxInterval) {
+		delay = float64(b.MaxInterval)
+	}
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented)
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Authenticator data flags set by SoftwareAuthenticator
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+)
+
+// SoftwareAuthenticator is an in-memory passkey for tests and backend-held keys.
+// It produces assertions in the same format as a platform authenticator.
+type SoftwareAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID string
+	rpID         string
+	origin       string
+
+	mu        sync.Mutex
+	signCount uint32
+}
+
+// NewSoftwareAuthenticator creates a software authenticator with a fresh P-256 key for the
+// relying party rpID, reporting origin in its client data.
+func NewSoftwareAuthenticator(rpID, origin string) (*SoftwareAuthenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate P-256 key: %w", err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate credential id: %w", err)
+	}
+
+	return &SoftwareAuthenticator{
+		key:          key,
+		credentialID: base64.RawURLEncoding.EncodeToString(id),
+		rpID:         rpID,
+		origin:       origin,
+	}, nil
+}
+
+// Credential returns the credential registered by the authenticator.
+func (a *SoftwareAuthenticator) Credential() Credential {
+	return Credential{ID: a.credentialID, PublicKey: &a.key.PublicKey}
+}
+
+// GetAssertion signs challenge as a webauthn.get response with user presence and verification set.
+func (a *SoftwareAuthenticator) GetAssertion(ctx context.Context, challenge []byte) (*Assertion, error) {
+	a.mu.Lock()
+	a.signCount++
+	signCount := a.signCount
+	a.mu.Unlock()
+
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	authenticatorData := make([]byte, 0, 37)
+	authenticatorData = append(authenticatorData, rpIDHash[:]...)
+	authenticatorData = append(authenticatorData, flagUserPresent|flagUserVerified)
+	authenticatorData = binary.BigEndian.AppendUint32(authenticatorData, signCount)
+
+	clientDataJSON, err := json.Marshal(struct {
+		Type        string `json:"type"`
+		Challenge   string `json:"challenge"`
+		Origin      string `json:"origin"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}{
+		Type:      "webauthn.get",
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode client data: %w", err)
+	}
+
+	assertion := &Assertion{
+		AuthenticatorData: authenticatorData,
+		ClientDataJSON:    string(clientDataJSON),
+	}
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, signedHash(assertion))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign assertion: %w", err)
+	}
+	assertion.Signature = signature
+	return assertion, nil
+}
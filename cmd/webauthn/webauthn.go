@@ -0,0 +1,265 @@
+// Package webauthn implements the Kernel v3 WebAuthn validator, letting P-256 passkeys own Kernel accounts.
+package webauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
+	"github.com/zerodevapp/sdk-go/cmd/types"
+	"github.com/zerodevapp/sdk-go/cmd/userop"
+)
+
+// responseType is the clientDataJSON type member of an assertion, located by the validator.
+const responseType = `"type":"webauthn.get"`
+
+// ErrInvalidAssertion is returned when a WebAuthn assertion does not verify against a credential and challenge.
+var ErrInvalidAssertion = errors.New("invalid webauthn assertion")
+
+var (
+	uint256Type, _    = abi.NewType("uint256", "", nil)
+	bytes32Type, _    = abi.NewType("bytes32", "", nil)
+	bytesType, _      = abi.NewType("bytes", "", nil)
+	stringType, _     = abi.NewType("string", "", nil)
+	boolType, _       = abi.NewType("bool", "", nil)
+	publicKeyType, _  = abi.NewType("tuple", "", []abi.ArgumentMarshaling{{Name: "x", Type: "uint256"}, {Name: "y", Type: "uint256"}})
+	installDataArgs   = abi.Arguments{{Type: publicKeyType}, {Type: bytes32Type}}
+	signatureDataArgs = abi.Arguments{{Type: bytesType}, {Type: stringType}, {Type: uint256Type}, {Type: uint256Type}, {Type: uint256Type}, {Type: boolType}}
+
+	p256HalfN = new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+)
+
+// Credential is a registered passkey: its credential ID (base64url, as returned by the browser)
+// and its P-256 public key.
+type Credential struct {
+	ID        string
+	PublicKey *ecdsa.PublicKey
+}
+
+// Assertion holds the parts of a WebAuthn authentication response needed to build a validator signature.
+// Signature is the ASN.1 DER encoded ECDSA signature returned by the authenticator.
+type Assertion struct {
+	AuthenticatorData []byte
+	ClientDataJSON    string
+	Signature         []byte
+}
+
+// Authenticator produces WebAuthn assertions over a challenge, e.g. a device passkey reached through
+// a mobile app or SoftwareAuthenticator.
+type Authenticator interface {
+	Credential() Credential
+	GetAssertion(ctx context.Context, challenge []byte) (*Assertion, error)
+}
+
+// clientData is the subset of clientDataJSON checked by Verify.
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+}
+
+// ecdsaSignature is the ASN.1 structure of a DER encoded ECDSA signature.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// NewCredential creates a credential from its ID and public key registration data, either a DER
+// SubjectPublicKeyInfo (as returned by the browser's getPublicKey()) or a 65-byte uncompressed P-256 point.
+func NewCredential(id string, publicKey []byte) (Credential, error) {
+	if _, err := credentialIDBytes(id); err != nil {
+		return Credential{}, err
+	}
+
+	if len(publicKey) == 65 && publicKey[0] == 0x04 {
+		x, y := new(big.Int).SetBytes(publicKey[1:33]), new(big.Int).SetBytes(publicKey[33:])
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return Credential{}, fmt.Errorf("public key is not a P-256 point")
+		}
+		return Credential{ID: id, PublicKey: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}}, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(publicKey)
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return Credential{}, fmt.Errorf("public key is not a P-256 ECDSA key")
+	}
+	return Credential{ID: id, PublicKey: key}, nil
+}
+
+// InstallData returns the WebAuthn validator install data of a credential:
+// abi.encode((x, y), keccak256(credentialId)), hashing the raw credential ID bytes decoded from base64url.
+func InstallData(credential Credential) ([]byte, error) {
+	if credential.PublicKey == nil {
+		return nil, fmt.Errorf("credential has no public key")
+	}
+	id, err := credentialIDBytes(credential.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := installDataArgs.Pack(
+		struct{ X, Y *big.Int }{credential.PublicKey.X, credential.PublicKey.Y},
+		crypto.Keccak256Hash(id),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webauthn install data: %w", err)
+	}
+	return encoded, nil
+}
+
+// ValidatorID returns the Kernel validator identifier of the WebAuthn validator.
+func ValidatorID() [21]byte {
+	return kernel.ValidatorID(kernel.ValidatorTypeValidator, common.HexToAddress(constants.WebAuthnValidatorAddress))
+}
+
+// GetInitData returns the Kernel initialize callData for an account owned by credential through the WebAuthn validator.
+func GetInitData(version constants.KernelVersion, credential Credential) ([]byte, error) {
+	installData, err := InstallData(credential)
+	if err != nil {
+		return nil, err
+	}
+	return kernel.GetInitData(version, ValidatorID(), installData)
+}
+
+// ComputeAccountAddress derives the counterfactual address of a Kernel account owned by credential.
+func ComputeAccountAddress(version constants.KernelVersion, credential Credential, index *big.Int) (common.Address, error) {
+	initData, err := GetInitData(version, credential)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return kernel.ComputeAccountAddress(version, initData, index)
+}
+
+// GetFactoryData returns the Factory and FactoryData pair deploying a Kernel account owned by credential.
+func GetFactoryData(version constants.KernelVersion, credential Credential, index *big.Int) (string, string, error) {
+	initData, err := GetInitData(version, credential)
+	if err != nil {
+		return "", "", err
+	}
+	return kernel.GetFactoryData(version, initData, index)
+}
+
+// EncodeSignature encodes an assertion into the WebAuthn validator signature format:
+// abi.encode(authenticatorData, clientDataJSON, responseTypeLocation, r, s, usePrecompile).
+// s is normalized to the lower half of the curve order. Set usePrecompile on chains with the RIP-7212 P-256 precompile.
+func EncodeSignature(assertion *Assertion, usePrecompile bool) ([]byte, error) {
+	r, s, err := parseSignature(assertion.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if s.Cmp(p256HalfN) > 0 {
+		s = new(big.Int).Sub(elliptic.P256().Params().N, s)
+	}
+
+	location := strings.LastIndex(assertion.ClientDataJSON, responseType)
+	if location < 0 {
+		return nil, fmt.Errorf("%w: clientDataJSON does not contain %s", ErrInvalidAssertion, responseType)
+	}
+
+	encoded, err := signatureDataArgs.Pack(assertion.AuthenticatorData, assertion.ClientDataJSON, big.NewInt(int64(location)), r, s, usePrecompile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webauthn signature: %w", err)
+	}
+	return encoded, nil
+}
+
+// Verify checks that assertion is a webauthn.get response over challenge signed by credential.
+// Returns an error wrapping ErrInvalidAssertion when it is not.
+func Verify(credential Credential, challenge []byte, assertion *Assertion) error {
+	var data clientData
+	if err := json.Unmarshal([]byte(assertion.ClientDataJSON), &data); err != nil {
+		return fmt.Errorf("%w: invalid clientDataJSON: %v", ErrInvalidAssertion, err)
+	}
+	if data.Type != "webauthn.get" {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidAssertion, data.Type)
+	}
+	if data.Challenge != base64.RawURLEncoding.EncodeToString(challenge) {
+		return fmt.Errorf("%w: challenge mismatch", ErrInvalidAssertion)
+	}
+	if len(assertion.AuthenticatorData) < 37 {
+		return fmt.Errorf("%w: authenticator data too short", ErrInvalidAssertion)
+	}
+
+	r, s, err := parseSignature(assertion.Signature)
+	if err != nil {
+		return err
+	}
+	if !ecdsa.Verify(credential.PublicKey, signedHash(assertion), r, s) {
+		return fmt.Errorf("%w: signature does not match credential %s", ErrInvalidAssertion, credential.ID)
+	}
+	return nil
+}
+
+// SignUserOpHash requests an assertion over userOpHash from authenticator, verifies it and encodes it
+// for the WebAuthn validator. Returns signature as hex string (0x-prefixed).
+func SignUserOpHash(ctx context.Context, authenticator Authenticator, userOpHash common.Hash, usePrecompile bool) (string, error) {
+	assertion, err := authenticator.GetAssertion(ctx, userOpHash.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to get webauthn assertion: %w", err)
+	}
+	if err := Verify(authenticator.Credential(), userOpHash.Bytes(), assertion); err != nil {
+		return "", err
+	}
+
+	signature, err := EncodeSignature(assertion, usePrecompile)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(signature), nil
+}
+
+// SignUserOp recomputes the userOpHash of a builder response locally and signs it with authenticator.
+// Refuses to sign when the computed hash does not match the UserOpHash returned by the builder.
+func SignUserOp(ctx context.Context, authenticator Authenticator, op *types.BuildUserOpResponse, entryPointVersion constants.EntryPointVersion, chainID uint64, usePrecompile bool) (string, error) {
+	userOpHash, err := userop.VerifyUserOpHash(op, entryPointVersion, chainID)
+	if err != nil {
+		return "", fmt.Errorf("refusing to sign user operation: %w", err)
+	}
+	return SignUserOpHash(ctx, authenticator, userOpHash, usePrecompile)
+}
+
+// credentialIDBytes decodes a base64url credential ID, with or without padding.
+func credentialIDBytes(id string) ([]byte, error) {
+	if id == "" {
+		return nil, fmt.Errorf("credential id is empty")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(id, "="))
+	if err != nil {
+		return nil, fmt.Errorf("credential id is not base64url: %w", err)
+	}
+	return raw, nil
+}
+
+// signedHash returns the digest signed by the authenticator: sha256(authenticatorData || sha256(clientDataJSON)).
+func signedHash(assertion *Assertion) []byte {
+	clientDataHash := sha256.Sum256([]byte(assertion.ClientDataJSON))
+	digest := sha256.Sum256(append(append([]byte{}, assertion.AuthenticatorData...), clientDataHash[:]...))
+	return digest[:]
+}
+
+// parseSignature decodes a DER encoded ECDSA signature.
+func parseSignature(signature []byte) (*big.Int, *big.Int, error) {
+	var sig ecdsaSignature
+	rest, err := asn1.Unmarshal(signature, &sig)
+	if err != nil || len(rest) > 0 || sig.R == nil || sig.S == nil || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("%w: malformed DER signature", ErrInvalidAssertion)
+	}
+	return sig.R, sig.S, nil
+}
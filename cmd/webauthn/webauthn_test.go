@@ -0,0 +1,130 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/elliptic"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// p256Generator is the uncompressed P-256 base point, used as a fixed public key.
+const p256Generator = "0x04" +
+	"6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296" +
+	"4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5"
+
+func TestInstallData(t *testing.T) {
+	point := hexutil.MustDecode(p256Generator)
+
+	tests := []struct {
+		name    string
+		id      string
+		want    string // keccak256 of the decoded credential ID
+		wantErr bool
+	}{
+		{"unpadded", "AQID", "0xf1885eda54b7a053318cd41e2093220dab15d65381b1157a3633a83bfd5c9239", false},
+		{"padded", "AQI=", "0x" + common.Bytes2Hex(crypto.Keccak256([]byte{0x01, 0x02})), false},
+		{"url alphabet", "-_8", "0x" + common.Bytes2Hex(crypto.Keccak256([]byte{0xfb, 0xff})), false},
+		{"empty", "", "", true},
+		{"not base64url", "AQ+/", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, err := NewCredential(tt.id, point)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewCredential() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewCredential() error = %v", err)
+			}
+
+			installData, err := InstallData(credential)
+			if err != nil {
+				t.Fatalf("InstallData() error = %v", err)
+			}
+			want := "0x" + p256Generator[4:] + tt.want[2:]
+			if got := hexutil.Encode(installData); got != want {
+				t.Errorf("InstallData() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestInstallDataSoftwareAuthenticator(t *testing.T) {
+	authenticator, err := NewSoftwareAuthenticator("example.com", "https://example.com")
+	if err != nil {
+		t.Fatalf("NewSoftwareAuthenticator() error = %v", err)
+	}
+	credential := authenticator.Credential()
+
+	installData, err := InstallData(credential)
+	if err != nil {
+		t.Fatalf("InstallData() error = %v", err)
+	}
+	id, err := base64.RawURLEncoding.DecodeString(credential.ID)
+	if err != nil {
+		t.Fatalf("credential id %q is not base64url: %v", credential.ID, err)
+	}
+	if got, want := common.BytesToHash(installData[64:]), crypto.Keccak256Hash(id); got != want {
+		t.Errorf("InstallData() credential id hash = %s, want %s", got.Hex(), want.Hex())
+	}
+}
+
+func TestSoftwareAuthenticatorAssertion(t *testing.T) {
+	ctx := context.Background()
+	authenticator, err := NewSoftwareAuthenticator("example.com", "https://example.com")
+	if err != nil {
+		t.Fatalf("NewSoftwareAuthenticator() error = %v", err)
+	}
+	other, err := NewSoftwareAuthenticator("example.com", "https://example.com")
+	if err != nil {
+		t.Fatalf("NewSoftwareAuthenticator() error = %v", err)
+	}
+
+	challenge := crypto.Keccak256([]byte("user operation"))
+	assertion, err := authenticator.GetAssertion(ctx, challenge)
+	if err != nil {
+		t.Fatalf("GetAssertion() error = %v", err)
+	}
+
+	if err := Verify(authenticator.Credential(), challenge, assertion); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := Verify(authenticator.Credential(), crypto.Keccak256([]byte("other")), assertion); !errors.Is(err, ErrInvalidAssertion) {
+		t.Errorf("Verify() with another challenge error = %v, want %v", err, ErrInvalidAssertion)
+	}
+	if err := Verify(other.Credential(), challenge, assertion); !errors.Is(err, ErrInvalidAssertion) {
+		t.Errorf("Verify() with another credential error = %v, want %v", err, ErrInvalidAssertion)
+	}
+
+	signature, err := SignUserOpHash(ctx, authenticator, common.BytesToHash(challenge), true)
+	if err != nil {
+		t.Fatalf("SignUserOpHash() error = %v", err)
+	}
+	values, err := signatureDataArgs.Unpack(hexutil.MustDecode(signature))
+	if err != nil {
+		t.Fatalf("failed to decode signature: %v", err)
+	}
+	clientDataJSON := values[1].(string)
+	location := values[2].(*big.Int)
+	s := values[4].(*big.Int)
+	if !strings.HasPrefix(clientDataJSON[location.Int64():], responseType) {
+		t.Errorf("responseTypeLocation %s does not point at %s in %s", location, responseType, clientDataJSON)
+	}
+	if s.Cmp(new(big.Int).Rsh(elliptic.P256().Params().N, 1)) > 0 {
+		t.Errorf("signature s %s is not in the lower half of the curve order", s)
+	}
+	if !values[5].(bool) {
+		t.Error("signature usePrecompile = false, want true")
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/joho/godotenv"
 	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
 	useropbuilder "github.com/zerodevapp/sdk-go/cmd/useropbuilder"
 )
 
@@ -64,6 +66,17 @@ func run4337Example() {
 	fmt.Println("\n=== Account ===")
 	fmt.Println("\tAddress:", addressHex)
 
+	//
+	//
+	// Derive the counterfactual Kernel account address
+	//
+	//
+	kernelAddress, err := kernel.ComputeKernelAddress(kernelVersion, constants.ECDSAValidatorAddress, addressHex, big.NewInt(0))
+	if err != nil {
+		log.Fatalf("Failed to compute kernel address: %v", err)
+	}
+	fmt.Println("\tKernel Account Address:", kernelAddress.Hex())
+
 	//
 	//
 	// Create UserOpBuilder client
@@ -8,7 +8,10 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/zerodevapp/sdk-go/cmd/constants"
+	"github.com/zerodevapp/sdk-go/cmd/eip7702"
+	"github.com/zerodevapp/sdk-go/cmd/kernel"
 	"github.com/zerodevapp/sdk-go/cmd/signer"
 	types "github.com/zerodevapp/sdk-go/cmd/types"
 	useropbuilder "github.com/zerodevapp/sdk-go/cmd/useropbuilder"
@@ -55,7 +58,9 @@ func run7702Example() {
 		log.Fatalf("Failed to generate private key: %v", err)
 	}
 	// Ethereum address from private key
-	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	// Any signer.Signer works here, e.g. signer.OpenKeystoreSigner or signer.NewRemoteSigner
+	accountSigner := signer.NewPrivateKeySigner(privateKey)
+	address := accountSigner.Address()
 	addressHex := address.Hex()
 	fmt.Println("\n=== Account ===")
 	fmt.Println("\tAddress:", addressHex)
@@ -65,15 +70,40 @@ func run7702Example() {
 	// Sign EIP-7702 authorization
 	//
 	//
-	authorization, err := signer.SignAuthorization(chainID, accountImplementationAddress, 0, privateKey)
-	if err != nil {
-		log.Fatalf("Failed to sign authorization: %v", err)
+	// With RPC_URL set, the EOA nonce is read from the chain and no authorization is signed
+	// when the EOA already delegates to the account implementation.
+	var authorization *types.SignedAuthorization
+	if rpcURL := os.Getenv("RPC_URL"); rpcURL != "" {
+		ethClient, err := ethclient.Dial(rpcURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to RPC: %v", err)
+		}
+		authorization, err = eip7702.NewEip7702(ethClient).AuthorizationFor(context.Background(), accountSigner, chainID, accountImplementationAddress)
+		if err != nil {
+			log.Fatalf("Failed to prepare authorization: %v", err)
+		}
+		ethClient.Close()
+	} else {
+		// A freshly generated EOA has nonce 0
+		authorization, err = accountSigner.SignAuthorization(context.Background(), types.Authorization{
+			ChainID: chainID,
+			Address: accountImplementationAddress,
+			Nonce:   0,
+		})
+		if err != nil {
+			log.Fatalf("Failed to sign authorization: %v", err)
+		}
+	}
+	if authorization == nil {
+		fmt.Println("\n=== Account already delegated, no authorization needed ===")
+	} else {
+		fmt.Println("\n=== Authorization Signed ===")
+		fmt.Printf("\tNonce: %d\n", authorization.Nonce)
+		fmt.Printf("\tR: %s\n", authorization.R)
+		fmt.Printf("\tS: %s\n", authorization.S)
+		fmt.Printf("\tV: %s\n", authorization.V)
+		fmt.Printf("\tYParity: %d\n", authorization.YParity)
 	}
-	fmt.Println("\n=== Authorization Signed ===")
-	fmt.Printf("\tR: %s\n", authorization.R)
-	fmt.Printf("\tS: %s\n", authorization.S)
-	fmt.Printf("\tV: %s\n", authorization.V)
-	fmt.Printf("\tYParity: %d\n", authorization.YParity)
 
 	//
 	//
@@ -127,6 +157,11 @@ func run7702Example() {
 	fmt.Printf("\n✓ UserOp built successfully!\n")
 	logJSON(buildUseropResponse)
 
+	// Make sure the builder encoded exactly the requested calls
+	if err := kernel.VerifyBuildResponse(buildReq, buildUseropResponse); err != nil {
+		log.Fatalf("Failed to verify built user op: %v", err)
+	}
+
 	//
 	//
 	// Sign the user operation hash
@@ -135,8 +170,8 @@ func run7702Example() {
 	fmt.Println("\n\n\n=== Step 2: Sign User Operation ===")
 	fmt.Printf("Signing hash: %s\n", buildUseropResponse.UserOpHash)
 
-	// Sign using go-ethereum's crypto.Sign with personal_sign format
-	signatureHex, err := signer.SignUserOpHash(buildUseropResponse.UserOpHash, privateKey)
+	// Recompute the hash locally and sign it with personal_sign format
+	signatureHex, err := signer.SignUserOp(context.Background(), buildUseropResponse, constants.EntryPointVersion(entrypointVersion), chainID, accountSigner)
 	if err != nil {
 		log.Fatalf("Failed to sign user op hash: %v", err)
 	}
@@ -206,6 +241,11 @@ func run7702Example() {
 	fmt.Printf("\n✓ UserOp built successfully!\n")
 	logJSON(buildUseropResponse2)
 
+	// Make sure the builder encoded exactly the requested calls
+	if err := kernel.VerifyBuildResponse(buildReq2, buildUseropResponse2); err != nil {
+		log.Fatalf("Failed to verify built user op: %v", err)
+	}
+
 	//
 	//
 	// Sign the user operation hash
@@ -214,8 +254,8 @@ func run7702Example() {
 	fmt.Println("\n\n\n=== Step 6: Sign User Operation Without Authorization ===")
 	fmt.Printf("Signing hash: %s\n", buildUseropResponse2.UserOpHash)
 
-	// Sign using go-ethereum's crypto.Sign with personal_sign format
-	signatureHex2, err := signer.SignUserOpHash(buildUseropResponse2.UserOpHash, privateKey)
+	// Recompute the hash locally and sign it with personal_sign format
+	signatureHex2, err := signer.SignUserOp(context.Background(), buildUseropResponse2, constants.EntryPointVersion(entrypointVersion), chainID, accountSigner)
 	if err != nil {
 		log.Fatalf("Failed to sign user op hash: %v", err)
 	}